@@ -0,0 +1,103 @@
+// Command render renders an obj, gltf, stl or ply model into a png file, without opening a window
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go-3d-rasterizer/headless"
+	m "go-3d-rasterizer/math3d"
+	"go-3d-rasterizer/obj"
+	"math"
+	"os"
+)
+
+// views maps the names of the predefined views to the camera pitch and yaw in degrees
+var views = map[string][2]float64{
+	"front":  {0, 0},
+	"back":   {0, 180},
+	"left":   {0, -90},
+	"right":  {0, 90},
+	"top":    {-90, 0},
+	"bottom": {90, 0},
+}
+
+func main() {
+	opts := headless.DefaultOptions()
+	out := flag.String("o", "out.png", "output png file")
+	scale := flag.Float64("scale", 2, "size of the normalized model")
+	pitch := flag.Float64("pitch", opts.Camera.Pitch*180./math.Pi, "camera pitch in degrees")
+	yaw := flag.Float64("yaw", 0, "camera yaw in degrees")
+	light := flag.Float64("light", -1, "angle of the light source around the y-axis in degrees (180 is behind the camera), disables lighting if negative")
+	flag.IntVar(&opts.Width, "width", opts.Width, "image width")
+	flag.IntVar(&opts.Height, "height", opts.Height, "image height")
+	flag.Float64Var(&opts.Camera.Distance, "distance", opts.Camera.Distance, "camera distance")
+	flag.Float64Var(&opts.Camera.Fov, "fov", opts.Camera.Fov, "field of view in degrees")
+	flag.BoolVar(&opts.Camera.Orthographic, "ortho", false, "use an orthographic projection")
+	view := flag.String("view", "", "predefined view which overrides pitch and yaw: front, back, left, right, top or bottom")
+	flag.BoolVar(&opts.Wireframe, "wireframe", false, "render in wireframe mode")
+	group := flag.String("group", "", "render only the group with this name")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] model.obj|model.gltf|model.glb|model.stl|model.ply\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *view != "" {
+		angles, ok := views[*view]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown view %q\n", *view)
+			os.Exit(2)
+		}
+		*pitch, *yaw = angles[0], angles[1]
+	}
+
+	model, err := obj.LoadFile(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *group != "" {
+		g := model.Group(*group)
+		if g == nil {
+			fmt.Fprintf(os.Stderr, "group %q not found\n", *group)
+			os.Exit(1)
+		}
+		for _, other := range model.Groups() {
+			other.Visible = other == g
+		}
+	}
+	model.CenterVertices()
+	model.NormalizeVertices(*scale)
+	if !model.HasNormals() {
+		model.GenerateNormals(obj.NormalsSmoothAngle, 60)
+	}
+	if !model.HasTangents() {
+		model.GenerateTangents()
+	}
+
+	opts.Camera.Pitch = *pitch * math.Pi / 180.
+	opts.Camera.Yaw = *yaw * math.Pi / 180.
+	if *light >= 0 {
+		rot := *light * math.Pi / 180.
+		opts.Lighting = true
+		opts.LightDirection = m.Vector{X: math.Sin(rot), Y: 0, Z: math.Cos(rot), W: 1}
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := headless.RenderPNG(file, model, opts); err != nil {
+		file.Close()
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := file.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
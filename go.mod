@@ -1,5 +1,5 @@
 module go-3d-rasterizer
 
-go 1.15
+go 1.16
 
 require github.com/gen2brain/raylib-go v0.0.0-20201123133337-d123299701ae
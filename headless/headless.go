@@ -0,0 +1,86 @@
+// Package headless renders models offscreen, without opening a window.
+// It only depends on the standard library, so it can be used without cgo on machines with no display
+package headless
+
+import (
+	m "go-3d-rasterizer/math3d"
+	"go-3d-rasterizer/obj"
+	"go-3d-rasterizer/rasterizer"
+	"image"
+	"image/png"
+	"io"
+	"math"
+)
+
+// Camera describes from where the model is viewed. The camera looks at the origin from Distance units away,
+// after the model has been rotated by Yaw around the y-axis and by Pitch around the x-axis
+type Camera struct {
+	Distance float64
+	Pitch    float64 // radians
+	Yaw      float64 // radians
+	Fov      float64 // degrees
+	ZNear    float64
+	ZFar     float64
+	// Orthographic disables the perspective, the visible area at the origin is the same as with the perspective
+	Orthographic bool
+}
+
+// Options configures a headless render
+type Options struct {
+	Width      int
+	Height     int
+	Camera     Camera
+	Background m.Vector
+	Wireframe  bool
+	// LightDirection is only used if Lighting is enabled
+	Lighting       bool
+	LightDirection m.Vector
+}
+
+// DefaultOptions returns the same view the interactive viewer starts with
+func DefaultOptions() Options {
+	return Options{
+		Width:  1200,
+		Height: 800,
+		Camera: Camera{
+			Distance: 3,
+			Pitch:    -10. * math.Pi / 180.,
+			Fov:      90,
+			ZNear:    1,
+			ZFar:     1000,
+		},
+		Background:     m.Vector{X: 0.5, Y: 0.5, Z: 0.5, W: 1},
+		LightDirection: m.Vector{X: 0, Y: 0, Z: -1, W: 1},
+	}
+}
+
+// NewScene creates a scene which is set up for the given options
+func NewScene(opts Options) *rasterizer.Scene {
+	w, h := float64(opts.Width), float64(opts.Height)
+	scene := rasterizer.NewScene(w, h, opts.Camera.Fov, opts.Camera.ZNear, opts.Camera.ZFar)
+	cam := scene.Camera()
+	cam.Orbit(m.Vector{X: 0, Y: 0, Z: 0, W: 1}, opts.Camera.Distance, opts.Camera.Pitch, opts.Camera.Yaw)
+	if opts.Camera.Orthographic {
+		cam.Projection = rasterizer.Orthographic
+	}
+	scene.SetCamera(cam)
+	return scene
+}
+
+// Render draws the model into a new scene and returns the frame buffer as an image
+func Render(model *obj.Model, opts Options) *image.NRGBA {
+	scene := NewScene(opts)
+	scene.EnableTiledRendering(64)
+	scene.ClearBuffers(opts.Background)
+	if opts.Wireframe {
+		model.RenderWireframe(scene)
+	} else {
+		model.Render(scene, opts.Lighting, opts.LightDirection)
+	}
+	return scene.Image()
+}
+
+// RenderPNG draws the model and writes the result as png
+func RenderPNG(w io.Writer, model *obj.Model, opts Options) error {
+	return png.Encode(w, Render(model, opts))
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	m "go-3d-rasterizer/math3d"
 	"go-3d-rasterizer/obj"
 	r "go-3d-rasterizer/rasterizer"
@@ -28,12 +29,16 @@ var (
 	startTime         time.Time  = time.Now()
 	renderNormals     bool       = false
 	selectedModel     int        = 0
+	selectedGroup     int        = -1 // -1 shows all groups
 	models            []*obj.Model
 
-	zoom        float64 = -3
-	mode        int     = 1
-	autoRotate  bool    = true
-	useLighting bool    = false
+	zoom        float64      = -3
+	mode        int          = 1
+	autoRotate  bool         = true
+	useLighting bool         = false
+	projection  r.Projection = r.Perspective
+
+	cullModeNames = []string{"none", "back", "front"}
 
 	modelFiles = []modelFile{
 		{"./assets/teapot.obj", 2.},
@@ -45,12 +50,18 @@ var (
 func loadModels() {
 	models = []*obj.Model{}
 	for _, mf := range modelFiles {
-		model, err := obj.ParseFile(mf.fn)
+		model, err := obj.LoadFile(mf.fn)
 		if err != nil {
 			continue
 		}
 		model.CenterVertices()
 		model.NormalizeVertices(mf.scale)
+		if !model.HasNormals() {
+			model.GenerateNormals(obj.NormalsSmoothAngle, 60)
+		}
+		if !model.HasTangents() {
+			model.GenerateTangents()
+		}
 		models = append(models, model)
 	}
 }
@@ -64,7 +75,16 @@ func handleInput() {
 		mode = (mode + 1) % 2
 	}
 	if rl.IsMouseButtonPressed(rl.MouseLeftButton) {
+		models[selectedModel].ShowAllGroups()
 		selectedModel = (selectedModel + 1) % len(models)
+		selectedGroup = -1
+	}
+	if rl.IsKeyPressed(rl.KeyG) {
+		groups := models[selectedModel].Groups()
+		selectedGroup = (selectedGroup+2)%(len(groups)+1) - 1
+		for i, g := range groups {
+			g.Visible = selectedGroup == -1 || selectedGroup == i
+		}
 	}
 	if rl.IsKeyPressed(rl.KeyN) {
 		renderNormals = !renderNormals
@@ -75,6 +95,15 @@ func handleInput() {
 	if rl.IsKeyPressed(rl.KeyL) {
 		useLighting = !useLighting
 	}
+	if rl.IsKeyPressed(rl.KeyP) {
+		scene.AffineInterpolation = !scene.AffineInterpolation
+	}
+	if rl.IsKeyPressed(rl.KeyO) {
+		projection = (projection + 1) % 2
+	}
+	if rl.IsKeyPressed(rl.KeyC) {
+		scene.CullMode = (scene.CullMode + 1) % 3
+	}
 	mw := rl.GetMouseWheelMove()
 	if mw > 0 {
 		zoom += 0.5
@@ -87,10 +116,10 @@ func handleInput() {
 func render() {
 	dt := time.Now().Sub(startTime).Seconds()
 
-	scene.ModelViewMatrix = m.IdentityMatrix()
-	scene.ModelViewMatrix = m.Translate(scene.ModelViewMatrix, 0, 0, zoom)
-	scene.ModelViewMatrix = m.Rotate(scene.ModelViewMatrix, -10.*math.Pi/180., 1, 0, 0)
-	scene.ModelViewMatrix = m.Rotate(scene.ModelViewMatrix, dt, 0, 1, 0)
+	camera := scene.Camera()
+	camera.Orbit(m.Vector{X: 0, Y: 0, Z: 0, W: 1}, -zoom, -10.*math.Pi/180., dt)
+	camera.Projection = projection
+	scene.SetCamera(camera)
 
 	scene.ClearBuffers(m.Vector{X: 0.5, Y: 0.5, Z: 0.5, W: 1})
 	scene.DrawAxisLines(1)
@@ -117,6 +146,18 @@ func render() {
 	}
 }
 
+// groupName returns the name of the selected group of the selected model
+func groupName() string {
+	if selectedGroup == -1 {
+		return "all"
+	}
+	g := models[selectedModel].Groups()[selectedGroup]
+	if g.Object != "" && g.Object != g.Name {
+		return g.Object + "/" + g.Name
+	}
+	return g.Name
+}
+
 func createFrameBuffer(width, height int) rl.Texture2D {
 	img := rl.GenImageColor(width, height, rl.White)
 	defer rl.UnloadImage(img)
@@ -136,6 +177,7 @@ func updateFrameBuffer() {
 
 func main() {
 	loadModels()
+	scene.EnableTiledRendering(64)
 	rl.InitWindow(width, height, title)
 	rl.SetTargetFPS(120)
 	frameBuffer := createFrameBuffer(width, height)
@@ -143,6 +185,7 @@ func main() {
 		rl.BeginDrawing()
 		handleInput()
 		render()
+		scene.Flush()
 		updateFrameBuffer()
 		rl.UpdateTexture(frameBuffer, raylibFramebuffer)
 		rl.DrawTexture(frameBuffer, 0, 0, rl.White)
@@ -152,6 +195,11 @@ func main() {
 		rl.DrawText("L - toggle light", 5, 120, 20, rl.Black)
 		rl.DrawText("N - toggle normals", 5, 150, 20, rl.Black)
 		rl.DrawText("A - toggle auto rotation", 5, 180, 20, rl.Black)
+		rl.DrawText("P - toggle perspective correct interpolation", 5, 210, 20, rl.Black)
+		rl.DrawText(fmt.Sprintf("C - cycle cull mode (%s), culled: %d/%d, objects: %d", cullModeNames[scene.CullMode],
+			scene.Stats.CulledTriangles, scene.Stats.Triangles, scene.Stats.CulledObjects), 5, 240, 20, rl.Black)
+		rl.DrawText("G - cycle groups ("+groupName()+")", 5, 270, 20, rl.Black)
+		rl.DrawText("O - toggle orthographic projection", 5, 300, 20, rl.Black)
 		rl.DrawFPS(5, 5)
 		rl.EndDrawing()
 	}
@@ -0,0 +1,313 @@
+package math3d
+
+import "math"
+
+// Intersection classifies a volume against a plane or a frustum
+type Intersection int
+
+// intersections, Outside is behind a plane or outside of a frustum
+const (
+	Outside Intersection = iota
+	Intersecting
+	Inside
+)
+
+// AABB is an axis aligned bounding box, Min is greater than Max on every axis if it is empty
+type AABB struct {
+	Min Vector
+	Max Vector
+}
+
+// EmptyAABB returns a bounding box which contains nothing, extending it by a vector contains only the vector
+func EmptyAABB() AABB {
+	return AABB{
+		Min: Vector{X: math.Inf(1), Y: math.Inf(1), Z: math.Inf(1), W: 1},
+		Max: Vector{X: math.Inf(-1), Y: math.Inf(-1), Z: math.Inf(-1), W: 1},
+	}
+}
+
+// NewAABB calculates the bounding box of the vectors
+func NewAABB(vectors ...Vector) AABB {
+	b := EmptyAABB()
+	for _, v := range vectors {
+		b = b.Extend(v)
+	}
+	return b
+}
+
+// IsEmpty checks whether the bounding box contains nothing
+func (b AABB) IsEmpty() bool {
+	return b.Min.X > b.Max.X || b.Min.Y > b.Max.Y || b.Min.Z > b.Max.Z
+}
+
+// Extend returns the bounding box extended by v
+func (b AABB) Extend(v Vector) AABB {
+	b.Min.X, b.Max.X = math.Min(b.Min.X, v.X), math.Max(b.Max.X, v.X)
+	b.Min.Y, b.Max.Y = math.Min(b.Min.Y, v.Y), math.Max(b.Max.Y, v.Y)
+	b.Min.Z, b.Max.Z = math.Min(b.Min.Z, v.Z), math.Max(b.Max.Z, v.Z)
+	return b
+}
+
+// Union returns the bounding box which contains both bounding boxes
+func (b AABB) Union(c AABB) AABB {
+	if c.IsEmpty() {
+		return b
+	}
+	return b.Extend(c.Min).Extend(c.Max)
+}
+
+// Contains checks whether v is inside of the bounding box or on its boundary
+func (b AABB) Contains(v Vector) bool {
+	return v.X >= b.Min.X && v.X <= b.Max.X &&
+		v.Y >= b.Min.Y && v.Y <= b.Max.Y &&
+		v.Z >= b.Min.Z && v.Z <= b.Max.Z
+}
+
+// Center returns the center of the bounding box
+func (b AABB) Center() Vector {
+	return Mul(Add(b.Min, b.Max), 0.5)
+}
+
+// Size returns the extent of the bounding box on every axis
+func (b AABB) Size() Vector {
+	size := Sub(b.Max, b.Min)
+	size.W = 0
+	return size
+}
+
+// Transform returns the bounding box of the transformed box, m has to be affine
+// source: https://github.com/erich666/GraphicsGems/blob/master/gems/TransBox.c
+func (b AABB) Transform(m Matrix) AABB {
+	if b.IsEmpty() {
+		return b
+	}
+	ret := AABB{Min: m.W, Max: m.W}
+	ret.Min.W, ret.Max.W = 1, 1
+	min, max := b.Min.ToArray(), b.Max.ToArray()
+	cols := [3]Vector{m.X, m.Y, m.Z}
+	for i, col := range cols {
+		a, c := Mul(col, min[i]), Mul(col, max[i])
+		ret.Min = Add(ret.Min, Vector{X: math.Min(a.X, c.X), Y: math.Min(a.Y, c.Y), Z: math.Min(a.Z, c.Z)})
+		ret.Max = Add(ret.Max, Vector{X: math.Max(a.X, c.X), Y: math.Max(a.Y, c.Y), Z: math.Max(a.Z, c.Z)})
+	}
+	return ret
+}
+
+// BoundingSphere returns the sphere around the bounding box
+func (b AABB) BoundingSphere() Sphere {
+	return Sphere{Center: b.Center(), Radius: Magnitude(b.Size()) / 2}
+}
+
+// Sphere is described by its center and radius
+type Sphere struct {
+	Center Vector
+	Radius float64
+}
+
+// Contains checks whether v is inside of the sphere or on its surface
+func (s Sphere) Contains(v Vector) bool {
+	return Magnitude(Sub(v, s.Center)) <= s.Radius
+}
+
+// Plane holds the points p with Dot(Normal, p) + D = 0, the normal points to the front
+type Plane struct {
+	Normal Vector
+	D      float64
+}
+
+// NewPlane creates the plane through point with the normal
+func NewPlane(point, normal Vector) Plane {
+	normal = Normalize(Vector{X: normal.X, Y: normal.Y, Z: normal.Z})
+	return Plane{Normal: normal, D: -Dot(normal, point)}
+}
+
+// PlaneFromPoints creates the plane through the points a, b, c, its front is counterclockwise
+func PlaneFromPoints(a, b, c Vector) Plane {
+	return NewPlane(a, Cross(Sub(b, a), Sub(c, a)))
+}
+
+// Normalize scales the plane to a normal with the size 1, distances are in world units afterwards
+func (p Plane) Normalize() Plane {
+	mag := Magnitude(p.Normal)
+	return Plane{Normal: Vector{X: p.Normal.X / mag, Y: p.Normal.Y / mag, Z: p.Normal.Z / mag}, D: p.D / mag}
+}
+
+// Distance returns the signed distance of v to the plane, which is negative behind it
+func (p Plane) Distance(v Vector) float64 {
+	return Dot(p.Normal, v) + p.D
+}
+
+// ClassifySphere checks whether the sphere is in front of the plane (Inside), behind it (Outside) or intersects it
+func (p Plane) ClassifySphere(s Sphere) Intersection {
+	d := p.Distance(s.Center)
+	switch {
+	case d < -s.Radius:
+		return Outside
+	case d > s.Radius:
+		return Inside
+	}
+	return Intersecting
+}
+
+// ClassifyAABB checks whether the bounding box is in front of the plane (Inside), behind it (Outside) or intersects it
+func (p Plane) ClassifyAABB(b AABB) Intersection {
+	// the distance of the center and the projection of the half size onto the normal
+	d := p.Distance(b.Center())
+	size := b.Size()
+	r := (math.Abs(p.Normal.X)*size.X + math.Abs(p.Normal.Y)*size.Y + math.Abs(p.Normal.Z)*size.Z) / 2
+	switch {
+	case d < -r:
+		return Outside
+	case d > r:
+		return Inside
+	}
+	return Intersecting
+}
+
+// Frustum holds the near, far, left, right, bottom and top planes of a view volume, their normals point inwards
+type Frustum struct {
+	Planes [6]Plane
+}
+
+// FrustumFromMatrix extracts the frustum of a (model) view projection matrix. The planes are in the space
+// which is transformed by the matrix, e.g. the frustum of projection * view is in world space
+// source: https://www.gamedevs.org/uploads/fast-extraction-viewing-frustum-planes-from-world-view-projection-matrix.pdf
+func FrustumFromMatrix(m Matrix) Frustum {
+	rows := [4]Vector{
+		{m.X.X, m.Y.X, m.Z.X, m.W.X},
+		{m.X.Y, m.Y.Y, m.Z.Y, m.W.Y},
+		{m.X.Z, m.Y.Z, m.Z.Z, m.W.Z},
+		{m.X.W, m.Y.W, m.Z.W, m.W.W},
+	}
+	// the clip space planes are -w <= z <= w, -w <= x <= w and -w <= y <= w
+	var f Frustum
+	for i, row := range [3]Vector{rows[2], rows[0], rows[1]} {
+		near := Vector{rows[3].X + row.X, rows[3].Y + row.Y, rows[3].Z + row.Z, rows[3].W + row.W}
+		far := Vector{rows[3].X - row.X, rows[3].Y - row.Y, rows[3].Z - row.Z, rows[3].W - row.W}
+		f.Planes[2*i] = Plane{Normal: Vector{X: near.X, Y: near.Y, Z: near.Z}, D: near.W}.Normalize()
+		f.Planes[2*i+1] = Plane{Normal: Vector{X: far.X, Y: far.Y, Z: far.Z}, D: far.W}.Normalize()
+	}
+	return f
+}
+
+// Contains checks whether v is inside of the frustum or on its boundary
+func (f Frustum) Contains(v Vector) bool {
+	for _, p := range f.Planes {
+		if p.Distance(v) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ClassifySphere checks whether the sphere is inside of the frustum, outside of it or intersects its boundary.
+// Spheres close to the corners can be reported as intersecting, although they are outside
+func (f Frustum) ClassifySphere(s Sphere) Intersection {
+	ret := Inside
+	for _, p := range f.Planes {
+		switch p.ClassifySphere(s) {
+		case Outside:
+			return Outside
+		case Intersecting:
+			ret = Intersecting
+		}
+	}
+	return ret
+}
+
+// ClassifyAABB checks whether the bounding box is inside of the frustum, outside of it or intersects its boundary.
+// Boxes close to the corners can be reported as intersecting, although they are outside
+func (f Frustum) ClassifyAABB(b AABB) Intersection {
+	if b.IsEmpty() {
+		return Outside
+	}
+	ret := Inside
+	for _, p := range f.Planes {
+		switch p.ClassifyAABB(b) {
+		case Outside:
+			return Outside
+		case Intersecting:
+			ret = Intersecting
+		}
+	}
+	return ret
+}
+
+// Ray starts at the origin and points along the direction
+type Ray struct {
+	Origin    Vector
+	Direction Vector
+}
+
+// At returns the point of the ray at the distance t, in multiples of the direction
+func (r Ray) At(t float64) Vector {
+	return Add(r.Origin, Mul(r.Direction, t))
+}
+
+// IntersectTriangle intersects the ray with the triangle a, b, c from both sides. It returns the distance t and
+// the barycentric coordinates u, v of the intersection, which is at LerpTri(a, b, c, u, v)
+// source: https://en.wikipedia.org/wiki/M%C3%B6ller%E2%80%93Trumbore_intersection_algorithm
+func (r Ray) IntersectTriangle(a, b, c Vector) (t, u, v float64, ok bool) {
+	const epsilon = 1e-12
+	edge1, edge2 := Sub(b, a), Sub(c, a)
+	p := Cross(r.Direction, edge2)
+	det := Dot(edge1, p)
+	// the ray is parallel to the triangle
+	if math.Abs(det) < epsilon {
+		return 0, 0, 0, false
+	}
+	s := Sub(r.Origin, a)
+	u = Dot(s, p) / det
+	if u < 0 || u > 1 {
+		return 0, 0, 0, false
+	}
+	q := Cross(s, edge1)
+	v = Dot(r.Direction, q) / det
+	if v < 0 || u+v > 1 {
+		return 0, 0, 0, false
+	}
+	t = Dot(edge2, q) / det
+	if t < 0 {
+		return 0, 0, 0, false
+	}
+	return t, u, v, true
+}
+
+// IntersectAABB intersects the ray with the bounding box. It returns the distances where the ray enters and
+// leaves the box, tMin is 0 if the ray starts inside of the box
+func (r Ray) IntersectAABB(b AABB) (tMin, tMax float64, ok bool) {
+	tMin, tMax = 0, math.Inf(1)
+	origin, dir := r.Origin.ToArray(), r.Direction.ToArray()
+	min, max := b.Min.ToArray(), b.Max.ToArray()
+	for i := 0; i < 3; i++ {
+		// the division by zero gives infinite distances for rays which are parallel to the slab
+		t1, t2 := (min[i]-origin[i])/dir[i], (max[i]-origin[i])/dir[i]
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		// NaNs of rays on the boundary of a slab keep the previous distance
+		if t1 > tMin {
+			tMin = t1
+		}
+		if t2 < tMax {
+			tMax = t2
+		}
+		if tMin > tMax {
+			return 0, 0, false
+		}
+	}
+	return tMin, tMax, true
+}
+
+// IntersectPlane intersects the ray with the plane from both sides and returns the distance of the intersection
+func (r Ray) IntersectPlane(p Plane) (float64, bool) {
+	denom := Dot(p.Normal, r.Direction)
+	if denom == 0 {
+		return 0, false
+	}
+	t := -p.Distance(r.Origin) / denom
+	if t < 0 {
+		return 0, false
+	}
+	return t, true
+}
@@ -33,10 +33,87 @@ func (m *Matrix) LoadIdentity() {
 // Transpose mirrors the content of the matrix diagonally
 func (m *Matrix) Transpose() {
 	m.X.Y, m.Y.X = m.Y.X, m.X.Y
-	m.X.Z, m.Y.Y, m.Z.X, m.Z.Y = m.Z.X, m.Z.Y, m.X.Z, m.Y.Y
+	m.X.Z, m.Z.X = m.Z.X, m.X.Z
+	m.Y.Z, m.Z.Y = m.Z.Y, m.Y.Z
 	m.X.W, m.Y.W, m.Z.W, m.W.X, m.W.Y, m.W.Z = m.W.X, m.W.Y, m.W.Z, m.X.W, m.Y.W, m.Z.W
 }
 
+// laplace calculates the 2x2 sub-determinants of the upper two rows (s) and the lower two rows (c),
+// the determinant is the sum of their products
+// source: https://www.geometrictools.com/Documentation/LaplaceExpansionTheorem.pdf
+func laplace(m Matrix) (s, c [6]float64) {
+	s[0] = m.X.X*m.Y.Y - m.X.Y*m.Y.X
+	s[1] = m.X.X*m.Z.Y - m.X.Y*m.Z.X
+	s[2] = m.X.X*m.W.Y - m.X.Y*m.W.X
+	s[3] = m.Y.X*m.Z.Y - m.Y.Y*m.Z.X
+	s[4] = m.Y.X*m.W.Y - m.Y.Y*m.W.X
+	s[5] = m.Z.X*m.W.Y - m.Z.Y*m.W.X
+
+	c[0] = m.X.Z*m.Y.W - m.X.W*m.Y.Z
+	c[1] = m.X.Z*m.Z.W - m.X.W*m.Z.Z
+	c[2] = m.X.Z*m.W.W - m.X.W*m.W.Z
+	c[3] = m.Y.Z*m.Z.W - m.Y.W*m.Z.Z
+	c[4] = m.Y.Z*m.W.W - m.Y.W*m.W.Z
+	c[5] = m.Z.Z*m.W.W - m.Z.W*m.W.Z
+	return s, c
+}
+
+// Determinant returns the determinant of the matrix, it is 0 if the matrix can't be inverted
+func (m Matrix) Determinant() float64 {
+	s, c := laplace(m)
+	return s[0]*c[5] - s[1]*c[4] + s[2]*c[3] + s[3]*c[2] - s[4]*c[1] + s[5]*c[0]
+}
+
+// Inverse returns the inverse of the matrix, false is returned if the matrix can't be inverted
+func Inverse(m Matrix) (Matrix, bool) {
+	s, c := laplace(m)
+	det := s[0]*c[5] - s[1]*c[4] + s[2]*c[3] + s[3]*c[2] - s[4]*c[1] + s[5]*c[0]
+	if det == 0 {
+		return Matrix{}, false
+	}
+	inv := 1 / det
+	return Matrix{
+		X: Vector{
+			X: (m.Y.Y*c[5] - m.Z.Y*c[4] + m.W.Y*c[3]) * inv,
+			Y: (-m.X.Y*c[5] + m.Z.Y*c[2] - m.W.Y*c[1]) * inv,
+			Z: (m.X.Y*c[4] - m.Y.Y*c[2] + m.W.Y*c[0]) * inv,
+			W: (-m.X.Y*c[3] + m.Y.Y*c[1] - m.Z.Y*c[0]) * inv,
+		},
+		Y: Vector{
+			X: (-m.Y.X*c[5] + m.Z.X*c[4] - m.W.X*c[3]) * inv,
+			Y: (m.X.X*c[5] - m.Z.X*c[2] + m.W.X*c[1]) * inv,
+			Z: (-m.X.X*c[4] + m.Y.X*c[2] - m.W.X*c[0]) * inv,
+			W: (m.X.X*c[3] - m.Y.X*c[1] + m.Z.X*c[0]) * inv,
+		},
+		Z: Vector{
+			X: (m.Y.W*s[5] - m.Z.W*s[4] + m.W.W*s[3]) * inv,
+			Y: (-m.X.W*s[5] + m.Z.W*s[2] - m.W.W*s[1]) * inv,
+			Z: (m.X.W*s[4] - m.Y.W*s[2] + m.W.W*s[0]) * inv,
+			W: (-m.X.W*s[3] + m.Y.W*s[1] - m.Z.W*s[0]) * inv,
+		},
+		W: Vector{
+			X: (-m.Y.Z*s[5] + m.Z.Z*s[4] - m.W.Z*s[3]) * inv,
+			Y: (m.X.Z*s[5] - m.Z.Z*s[2] + m.W.Z*s[1]) * inv,
+			Z: (-m.X.Z*s[4] + m.Y.Z*s[2] - m.W.Z*s[0]) * inv,
+			W: (m.X.Z*s[3] - m.Y.Z*s[1] + m.Z.Z*s[0]) * inv,
+		},
+	}, true
+}
+
+// NormalMatrix returns the inverse transpose of the upper 3x3 matrix, which transforms normals so they stay
+// perpendicular to the surface under non-uniform scaling. Its columns are the cross products of the matrix columns
+// divided by the determinant, for singular matrices the cross products are returned as they are
+func NormalMatrix(m Matrix) Matrix {
+	x := Cross(m.Y, m.Z)
+	y := Cross(m.Z, m.X)
+	z := Cross(m.X, m.Y)
+	if det := Dot(m.X, x); det != 0 {
+		x, y, z = Mul(x, 1/det), Mul(y, 1/det), Mul(z, 1/det)
+	}
+	x.W, y.W, z.W = 0, 0, 0
+	return Matrix{X: x, Y: y, Z: z, W: Vector{0, 0, 0, 1}}
+}
+
 // Multiply returns the product of: m * n
 func Multiply(m, n Matrix) Matrix {
 	return Matrix{
@@ -67,7 +144,8 @@ func Multiply(m, n Matrix) Matrix {
 	}
 }
 
-// Transform returns a transformed vector, which is m * v
+// Transform returns a transformed vector, which is m * v. Normals are not translated, under non-uniform scaling
+// they have to be transformed with the NormalMatrix
 func Transform(m Matrix, v Vector, isNormalVec bool) Vector {
 	if !isNormalVec {
 		return Vector{
@@ -135,6 +213,42 @@ func ProjectionMatrix(fov, aspectRatio, zNear, zFar float64) Matrix {
 	}
 }
 
+// FrustumMatrix creates a perspective projection matrix for the view frustum whose near plane is bounded by
+// left, right, bottom and top
+func FrustumMatrix(left, right, bottom, top, zNear, zFar float64) Matrix {
+	return Matrix{
+		X: Vector{2 * zNear / (right - left), 0, 0, 0},
+		Y: Vector{0, 2 * zNear / (top - bottom), 0, 0},
+		Z: Vector{(right + left) / (right - left), (top + bottom) / (top - bottom), -(zFar + zNear) / (zFar - zNear), -1},
+		W: Vector{0, 0, -2 * zFar * zNear / (zFar - zNear), 0},
+	}
+}
+
+// OrthoMatrix creates an orthographic projection matrix, which maps the box between the left, right, bottom and top
+// planes and the clip planes to the clip space cube
+func OrthoMatrix(left, right, bottom, top, zNear, zFar float64) Matrix {
+	return Matrix{
+		X: Vector{2 / (right - left), 0, 0, 0},
+		Y: Vector{0, 2 / (top - bottom), 0, 0},
+		Z: Vector{0, 0, -2 / (zFar - zNear), 0},
+		W: Vector{-(right + left) / (right - left), -(top + bottom) / (top - bottom), -(zFar + zNear) / (zFar - zNear), 1},
+	}
+}
+
+// LookAt creates a view matrix for a camera at eye which looks at target, up is the direction which points upwards
+// on the screen. It must not be parallel to the viewing direction
+func LookAt(eye, target, up Vector) Matrix {
+	f := Normalize(Sub(target, eye))
+	s := Normalize(Cross(f, up))
+	u := Cross(s, f)
+	return Matrix{
+		X: Vector{s.X, u.X, -f.X, 0},
+		Y: Vector{s.Y, u.Y, -f.Y, 0},
+		Z: Vector{s.Z, u.Z, -f.Z, 0},
+		W: Vector{-Dot(s, eye), -Dot(u, eye), Dot(f, eye), 1},
+	}
+}
+
 // Viewport creates a viewport matrix for a window
 func Viewport(x, y, w, h float64) Matrix {
 	vp := IdentityMatrix()
@@ -0,0 +1,169 @@
+package math3d
+
+import (
+	"math"
+	"testing"
+)
+
+const epsilon = 1e-9
+
+func matrixNear(a, b Matrix) bool {
+	x, y := a.ToArray(), b.ToArray()
+	for i := range x {
+		if math.Abs(x[i]-y[i]) > epsilon {
+			return false
+		}
+	}
+	return true
+}
+
+func vectorNear(a, b Vector) bool {
+	return math.Abs(a.X-b.X) <= epsilon && math.Abs(a.Y-b.Y) <= epsilon &&
+		math.Abs(a.Z-b.Z) <= epsilon && math.Abs(a.W-b.W) <= epsilon
+}
+
+// general is an affine matrix with translation, rotation and non-uniform scaling
+func general() Matrix {
+	axis := Normalize(Vector{1, 2, 3, 0})
+	ret := Translate(IdentityMatrix(), 1, -2, 3)
+	ret = Rotate(ret, 0.7, axis.X, axis.Y, axis.Z)
+	return Scale(ret, 2, 0.5, 3)
+}
+
+func TestTranspose(t *testing.T) {
+	var m Matrix
+	m.FromArray([]float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15})
+	m.Transpose()
+	var want Matrix
+	want.FromArray([]float64{0, 4, 8, 12, 1, 5, 9, 13, 2, 6, 10, 14, 3, 7, 11, 15})
+	if m != want {
+		t.Errorf("Transpose() = %v, want %v", m, want)
+	}
+}
+
+func TestDeterminant(t *testing.T) {
+	singular := IdentityMatrix()
+	singular.Y = singular.X
+	tests := []struct {
+		name string
+		m    Matrix
+		want float64
+	}{
+		{"identity", IdentityMatrix(), 1},
+		{"scale", Scale(IdentityMatrix(), 2, 3, 4), 24},
+		{"mirrored", Scale(IdentityMatrix(), -1, 1, 1), -1},
+		{"rotation and translation", Rotate(Translate(IdentityMatrix(), 4, 5, 6), 1.2, 0, 0, 1), 1},
+		{"general", general(), 3},
+		{"singular", singular, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Determinant(); math.Abs(got-tt.want) > epsilon {
+			t.Errorf("%s: Determinant() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInverse(t *testing.T) {
+	singular := IdentityMatrix()
+	singular.Z = Vector{1, 1, 0, 0}
+	tests := []struct {
+		name string
+		m    Matrix
+		ok   bool
+	}{
+		{"identity", IdentityMatrix(), true},
+		{"mirrored", Scale(Translate(IdentityMatrix(), 1, 2, 3), 1, -2, 1), true},
+		{"general", general(), true},
+		{"projection", ProjectionMatrix(60, 1.5, 0.1, 100), true},
+		{"singular", singular, false},
+		{"zero", Matrix{}, false},
+	}
+	for _, tt := range tests {
+		inv, ok := Inverse(tt.m)
+		if ok != tt.ok {
+			t.Errorf("%s: Inverse() ok = %v, want %v", tt.name, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if got := Multiply(inv, tt.m); !matrixNear(got, IdentityMatrix()) {
+			t.Errorf("%s: Inverse(m) * m = %v, want identity", tt.name, got)
+		}
+		if got := Multiply(tt.m, inv); !matrixNear(got, IdentityMatrix()) {
+			t.Errorf("%s: m * Inverse(m) = %v, want identity", tt.name, got)
+		}
+	}
+
+	// known result
+	inv, _ := Inverse(Scale(Translate(IdentityMatrix(), 1, 2, 3), 2, 4, 8))
+	want := Translate(Scale(IdentityMatrix(), 0.5, 0.25, 0.125), -1, -2, -3)
+	if !matrixNear(inv, want) {
+		t.Errorf("Inverse() = %v, want %v", inv, want)
+	}
+}
+
+func TestNormalMatrix(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Matrix
+		want Matrix
+	}{
+		{"identity", IdentityMatrix(), IdentityMatrix()},
+		{"non-uniform scale", Scale(IdentityMatrix(), 2, 4, 8), Scale(IdentityMatrix(), 0.5, 0.25, 0.125)},
+		{"mirrored", Scale(IdentityMatrix(), -1, 2, 1), Scale(IdentityMatrix(), -1, 0.5, 1)},
+		{"translation is ignored", Translate(IdentityMatrix(), 5, 6, 7), IdentityMatrix()},
+	}
+	for _, tt := range tests {
+		if got := NormalMatrix(tt.m); !matrixNear(got, tt.want) {
+			t.Errorf("%s: NormalMatrix() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// the normal of the plane x + y = 0 stays perpendicular to the plane under non-uniform scaling
+	m := general()
+	tangent := Transform(m, Vector{1, -1, 0, 0}, true)
+	normal := Transform(NormalMatrix(m), Vector{1, 1, 0, 0}, true)
+	if d := Dot(tangent, normal); math.Abs(d) > epsilon {
+		t.Errorf("transformed normal is not perpendicular to the surface, dot product %v", d)
+	}
+}
+
+// ndc transforms v into normalized device coordinates
+func ndc(m Matrix, v Vector) Vector {
+	v = Transform(m, v, false)
+	return Vector{v.X / v.W, v.Y / v.W, v.Z / v.W, 1}
+}
+
+func TestProjections(t *testing.T) {
+	ortho := OrthoMatrix(-1, 3, -2, 2, 1, 9)
+	frustum := FrustumMatrix(-1, 3, -2, 2, 1, 9)
+	side := Multiply(OrthoMatrix(-1, 1, -1, 1, 1, 9), LookAt(Vector{5, 0, 0, 1}, Vector{0, 0, 0, 1}, Vector{0, 1, 0, 0}))
+	front := Multiply(OrthoMatrix(-1, 1, -1, 1, 1, 9), LookAt(Vector{0, 0, 5, 1}, Vector{0, 0, 0, 1}, Vector{0, 1, 0, 0}))
+	tests := []struct {
+		name string
+		m    Matrix
+		v    Vector
+		want Vector
+	}{
+		{"ortho near bottom left", ortho, Vector{-1, -2, -1, 1}, Vector{-1, -1, -1, 1}},
+		{"ortho far top right", ortho, Vector{3, 2, -9, 1}, Vector{1, 1, 1, 1}},
+		{"ortho center", ortho, Vector{1, 0, -5, 1}, Vector{0, 0, 0, 1}},
+		{"frustum near bottom left", frustum, Vector{-1, -2, -1, 1}, Vector{-1, -1, -1, 1}},
+		{"frustum near top right", frustum, Vector{3, 2, -1, 1}, Vector{1, 1, -1, 1}},
+		{"frustum far top right", frustum, Vector{27, 18, -9, 1}, Vector{1, 1, 1, 1}},
+		{"frustum far bottom left", frustum, Vector{-9, -18, -9, 1}, Vector{-1, -1, 1, 1}},
+		{"look at front near top right", front, Vector{1, 1, 4, 1}, Vector{1, 1, -1, 1}},
+		{"look at front far bottom left", front, Vector{-1, -1, -4, 1}, Vector{-1, -1, 1, 1}},
+		{"look at side near top right", side, Vector{4, 1, -1, 1}, Vector{1, 1, -1, 1}},
+		{"look at side far bottom left", side, Vector{-4, -1, 1, 1}, Vector{-1, -1, 1, 1}},
+	}
+	for _, tt := range tests {
+		if got := ndc(tt.m, tt.v); !vectorNear(got, tt.want) {
+			t.Errorf("%s: %v is mapped to %v, want %v", tt.name, tt.v, got, tt.want)
+		}
+	}
+	if got := Transform(LookAt(Vector{1, 2, 3, 1}, Vector{1, 2, 0, 1}, Vector{0, 1, 0, 0}), Vector{1, 2, 3, 1}, false); !vectorNear(got, Vector{0, 0, 0, 1}) {
+		t.Errorf("LookAt() does not move the eye to the origin: %v", got)
+	}
+}
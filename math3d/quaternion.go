@@ -0,0 +1,218 @@
+package math3d
+
+import "math"
+
+// Quaternion struct holds a rotation as the quaternion X*i + Y*j + Z*k + W
+type Quaternion struct {
+	X float64
+	Y float64
+	Z float64
+	W float64
+}
+
+// EulerOrder is the order in which euler angles are applied, the rotations are around the fixed world axes
+type EulerOrder int
+
+// euler orders, XYZ rotates around the x-axis first and around the z-axis last
+const (
+	XYZ EulerOrder = iota
+	XZY
+	YXZ
+	YZX
+	ZXY
+	ZYX
+)
+
+// axes returns the indices of the axes in the order they are applied
+func (o EulerOrder) axes() (i, j, k int) {
+	switch o {
+	case XZY:
+		return 0, 2, 1
+	case YXZ:
+		return 1, 0, 2
+	case YZX:
+		return 1, 2, 0
+	case ZXY:
+		return 2, 0, 1
+	case ZYX:
+		return 2, 1, 0
+	}
+	return 0, 1, 2
+}
+
+// IdentityQuaternion returns the quaternion which does not rotate
+func IdentityQuaternion() Quaternion {
+	return Quaternion{0, 0, 0, 1}
+}
+
+// QuaternionFromAxisAngle creates the counterclockwise rotation around the axis x, y, z.
+// It rotates in the opposite direction of Rotate, Rotate(m, -radians, x, y, z) rotates like the quaternion
+func QuaternionFromAxisAngle(radians, x, y, z float64) Quaternion {
+	axis := Normalize(Vector{x, y, z, 0})
+	s := math.Sin(radians / 2)
+	return Quaternion{axis.X * s, axis.Y * s, axis.Z * s, math.Cos(radians / 2)}
+}
+
+// AxisAngle returns the angle in radians and the axis of the rotation, the axis of the identity is the x-axis
+func (q Quaternion) AxisAngle() (float64, Vector) {
+	q = q.Normalize()
+	if q.W < 0 {
+		q = Quaternion{-q.X, -q.Y, -q.Z, -q.W}
+	}
+	s := math.Sqrt(q.X*q.X + q.Y*q.Y + q.Z*q.Z)
+	if s < 1e-12 {
+		return 0, Vector{1, 0, 0, 0}
+	}
+	return 2 * math.Atan2(s, q.W), Vector{q.X / s, q.Y / s, q.Z / s, 0}
+}
+
+// QuaternionFromEuler creates a rotation from euler angles in radians, which are applied in the given order
+func QuaternionFromEuler(x, y, z float64, order EulerOrder) Quaternion {
+	rotations := [3]Quaternion{
+		QuaternionFromAxisAngle(x, 1, 0, 0),
+		QuaternionFromAxisAngle(y, 0, 1, 0),
+		QuaternionFromAxisAngle(z, 0, 0, 1),
+	}
+	i, j, k := order.axes()
+	return rotations[k].Multiply(rotations[j]).Multiply(rotations[i])
+}
+
+// Euler returns the euler angles in radians for the given order, the inverse of QuaternionFromEuler.
+// The second angle is within [-pi/2, pi/2], if it is at a limit (gimbal lock) the last angle is 0
+// source: https://www.geometrictools.com/Documentation/EulerAngles.pdf
+func (q Quaternion) Euler(order EulerOrder) (x, y, z float64) {
+	rot := q.Matrix()
+	cols := [3]Vector{rot.X, rot.Y, rot.Z}
+	// r returns the element in the row and the column of the rotation matrix
+	r := func(row, col int) float64 {
+		return [3]float64{cols[col].X, cols[col].Y, cols[col].Z}[row]
+	}
+	i, j, k := order.axes()
+	// odd permutations of the axes mirror the signs
+	sign := 1.
+	if (j-i+3)%3 != 1 {
+		sign = -1
+	}
+	var angles [3]float64
+	sinJ := -sign * r(k, i)
+	switch {
+	case sinJ >= 1-1e-12:
+		angles[i] = math.Atan2(r(i, j), r(j, j))
+		angles[j] = math.Pi / 2
+	case sinJ <= -1+1e-12:
+		angles[i] = math.Atan2(-r(i, j), r(j, j))
+		angles[j] = -math.Pi / 2
+	default:
+		angles[i] = math.Atan2(sign*r(k, j), r(k, k))
+		angles[j] = math.Asin(sinJ)
+		angles[k] = math.Atan2(sign*r(j, i), r(i, i))
+	}
+	return angles[0], angles[1], angles[2]
+}
+
+// QuaternionFromMatrix extracts the rotation of a matrix without scaling or shearing
+// source: https://www.euclideanspace.com/maths/geometry/rotations/conversions/matrixToQuaternion/
+func QuaternionFromMatrix(m Matrix) Quaternion {
+	var q Quaternion
+	switch trace := m.X.X + m.Y.Y + m.Z.Z; {
+	case trace > 0:
+		s := 2 * math.Sqrt(trace+1)
+		q = Quaternion{(m.Y.Z - m.Z.Y) / s, (m.Z.X - m.X.Z) / s, (m.X.Y - m.Y.X) / s, s / 4}
+	case m.X.X > m.Y.Y && m.X.X > m.Z.Z:
+		s := 2 * math.Sqrt(1+m.X.X-m.Y.Y-m.Z.Z)
+		q = Quaternion{s / 4, (m.Y.X + m.X.Y) / s, (m.Z.X + m.X.Z) / s, (m.Y.Z - m.Z.Y) / s}
+	case m.Y.Y > m.Z.Z:
+		s := 2 * math.Sqrt(1+m.Y.Y-m.X.X-m.Z.Z)
+		q = Quaternion{(m.Y.X + m.X.Y) / s, s / 4, (m.Z.Y + m.Y.Z) / s, (m.Z.X - m.X.Z) / s}
+	default:
+		s := 2 * math.Sqrt(1+m.Z.Z-m.X.X-m.Y.Y)
+		q = Quaternion{(m.Z.X + m.X.Z) / s, (m.Z.Y + m.Y.Z) / s, s / 4, (m.X.Y - m.Y.X) / s}
+	}
+	return q.Normalize()
+}
+
+// Matrix returns the rotation matrix of the quaternion, which has to be normalized
+func (q Quaternion) Matrix() Matrix {
+	x, y, z, w := q.X, q.Y, q.Z, q.W
+	return Matrix{
+		X: Vector{1 - 2*(y*y+z*z), 2 * (x*y + z*w), 2 * (x*z - y*w), 0},
+		Y: Vector{2 * (x*y - z*w), 1 - 2*(x*x+z*z), 2 * (y*z + x*w), 0},
+		Z: Vector{2 * (x*z + y*w), 2 * (y*z - x*w), 1 - 2*(x*x+y*y), 0},
+		W: Vector{0, 0, 0, 1},
+	}
+}
+
+// Multiply returns q * r, which rotates by r first and by q afterwards
+func (q Quaternion) Multiply(r Quaternion) Quaternion {
+	return Quaternion{
+		X: q.W*r.X + q.X*r.W + q.Y*r.Z - q.Z*r.Y,
+		Y: q.W*r.Y - q.X*r.Z + q.Y*r.W + q.Z*r.X,
+		Z: q.W*r.Z + q.X*r.Y - q.Y*r.X + q.Z*r.W,
+		W: q.W*r.W - q.X*r.X - q.Y*r.Y - q.Z*r.Z,
+	}
+}
+
+// Conjugate returns the inverse rotation of a normalized quaternion
+func (q Quaternion) Conjugate() Quaternion {
+	return Quaternion{-q.X, -q.Y, -q.Z, q.W}
+}
+
+// Dot calculates the dot product of two quaternions, the cosine of half the angle between unit quaternions
+func (q Quaternion) Dot(r Quaternion) float64 {
+	return q.X*r.X + q.Y*r.Y + q.Z*r.Z + q.W*r.W
+}
+
+// Normalize sets the size of the quaternion to 1
+func (q Quaternion) Normalize() Quaternion {
+	mag := math.Sqrt(q.Dot(q))
+	return Quaternion{q.X / mag, q.Y / mag, q.Z / mag, q.W / mag}
+}
+
+// Rotate rotates the vector v, W is kept
+func (q Quaternion) Rotate(v Vector) Vector {
+	// v + 2w(u x v) + 2u x (u x v), with u being the vector part of q
+	u := Vector{q.X, q.Y, q.Z, 0}
+	t := Mul(Cross(u, v), 2)
+	ret := Add(Add(v, Mul(t, q.W)), Cross(u, t))
+	ret.W = v.W
+	return ret
+}
+
+// Nlerp interpolates between q and r using t [0, 1] along the shorter path and normalizes the result.
+// It is cheaper than Slerp, but its angular speed is not constant
+func Nlerp(q, r Quaternion, t float64) Quaternion {
+	if q.Dot(r) < 0 {
+		r = Quaternion{-r.X, -r.Y, -r.Z, -r.W}
+	}
+	return Quaternion{
+		X: q.X*(1-t) + r.X*t,
+		Y: q.Y*(1-t) + r.Y*t,
+		Z: q.Z*(1-t) + r.Z*t,
+		W: q.W*(1-t) + r.W*t,
+	}.Normalize()
+}
+
+// Slerp spherically interpolates between the unit quaternions q and r using t [0, 1] along the shorter path,
+// the rotation has a constant angular speed
+// source: https://en.wikipedia.org/wiki/Slerp
+func Slerp(q, r Quaternion, t float64) Quaternion {
+	cos := q.Dot(r)
+	if cos < 0 {
+		r = Quaternion{-r.X, -r.Y, -r.Z, -r.W}
+		cos = -cos
+	}
+	// nearly parallel quaternions would divide by a sine close to 0
+	if cos > 0.9995 {
+		return Nlerp(q, r, t)
+	}
+	theta := math.Acos(cos)
+	sin := math.Sin(theta)
+	a := math.Sin((1-t)*theta) / sin
+	b := math.Sin(t*theta) / sin
+	return Quaternion{
+		X: q.X*a + r.X*b,
+		Y: q.Y*a + r.Y*b,
+		Z: q.Z*a + r.Z*b,
+		W: q.W*a + r.W*b,
+	}
+}
@@ -97,29 +97,13 @@ func LerpTri(v, w, u Vector, s, t float64) Vector {
 	return Add(Add(Mul(v, 1.-s-t), Mul(w, s)), Mul(u, t))
 }
 
-// CalculateBoundingBox calculates the bounding box for given vectors
+// CalculateBoundingBox calculates the bounding box for given vectors, it is zero if there are no vectors
+//
+// Deprecated: use NewAABB
 func CalculateBoundingBox(vectors ...Vector) (Vector, Vector) {
-	var min, max Vector
-
-	firstIteration := true
-	for _, v := range vectors {
-		if firstIteration {
-			min = v
-			max = v
-			firstIteration = false
-			continue
-		}
-		if v.X < min.X {
-			min.X = v.X
-		} else if v.X > max.X {
-			max.X = v.X
-		}
-		if v.Y < min.Y {
-			min.Y = v.Y
-		} else if v.Y > max.Y {
-			max.Y = v.Y
-		}
+	if len(vectors) == 0 {
+		return Vector{}, Vector{}
 	}
-
-	return min, max
+	b := NewAABB(vectors...)
+	return b.Min, b.Max
 }
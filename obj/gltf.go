@@ -0,0 +1,781 @@
+package obj
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	m "go-3d-rasterizer/math3d"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// gltf json structures, only the properties used by the importer are listed.
+// encoding/json matches the field names case insensitively
+// source: https://www.khronos.org/registry/glTF/specs/2.0/glTF-2.0.html
+type gltfDocument struct {
+	ExtensionsRequired []string
+	Scene              *int
+	Scenes             []struct {
+		Nodes []int
+	}
+	Nodes       []gltfNode
+	Meshes      []gltfMesh
+	Accessors   []gltfAccessor
+	BufferViews []gltfBufferView
+	Buffers     []gltfBuffer
+	Materials   []gltfMaterial
+	Textures    []gltfTexture
+	Images      []gltfImage
+	Samplers    []gltfSampler
+}
+
+type gltfNode struct {
+	Name        string
+	Mesh        *int
+	Children    []int
+	Matrix      []float64 // column major
+	Translation []float64
+	Rotation    []float64 // quaternion x, y, z, w
+	Scale       []float64
+}
+
+type gltfMesh struct {
+	Name       string
+	Primitives []gltfPrimitive
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int
+	Indices    *int
+	Material   *int
+	Mode       *int
+}
+
+type gltfAccessor struct {
+	BufferView    *int
+	ByteOffset    int
+	ComponentType int
+	Normalized    bool
+	Count         int
+	Type          string
+	Sparse        json.RawMessage
+}
+
+type gltfBufferView struct {
+	Buffer     int
+	ByteOffset int
+	ByteLength int
+	ByteStride int
+}
+
+type gltfBuffer struct {
+	URI        string
+	ByteLength int
+}
+
+type gltfTextureInfo struct {
+	Index    int
+	TexCoord int
+	Scale    *float64 // only used by normal textures
+}
+
+type gltfMaterial struct {
+	Name                 string
+	PbrMetallicRoughness *struct {
+		BaseColorFactor          []float64
+		BaseColorTexture         *gltfTextureInfo
+		MetallicFactor           *float64
+		RoughnessFactor          *float64
+		MetallicRoughnessTexture *gltfTextureInfo
+	}
+	NormalTexture    *gltfTextureInfo
+	OcclusionTexture *gltfTextureInfo
+	EmissiveTexture  *gltfTextureInfo
+	EmissiveFactor   []float64
+	AlphaMode        string
+	AlphaCutoff      *float64
+	Extensions       map[string]json.RawMessage
+}
+
+type gltfTexture struct {
+	Sampler *int
+	Source  *int
+}
+
+type gltfImage struct {
+	URI        string
+	BufferView *int
+	MimeType   string
+}
+
+type gltfSampler struct {
+	WrapS int
+	WrapT int
+}
+
+// gltf constants
+const (
+	gltfModeTriangles     = 4
+	gltfModeTriangleStrip = 5
+	gltfModeTriangleFan   = 6
+
+	gltfWrapClampToEdge = 33071
+
+	glbMagic     = 0x46546c67 // "glTF"
+	glbChunkJSON = 0x4e4f534a
+	glbChunkBIN  = 0x004e4942
+)
+
+// required extensions which are supported by the importer
+var gltfSupportedExtensions = map[string]bool{
+	"KHR_materials_unlit": true,
+}
+
+// gltfLoader holds the state of the gltf importer
+type gltfLoader struct {
+	diagnostics
+	doc      gltfDocument
+	file     string
+	dir      string
+	bin      []byte // binary chunk of a glb file
+	buffers  [][]byte
+	textures map[int]*Texture // by image index
+	model    *Model
+}
+
+// ParseGLTF loads a gltf 2.0 model, either a .gltf file with external or base64 encoded buffers or a binary .glb file.
+// The meshes of all nodes of the default scene are transformed by the node hierarchy, every node becomes a group.
+// Metallic-roughness materials are approximated with the blinn-phong lighting model
+func ParseGLTF(filename string) (*Model, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	l := &gltfLoader{
+		file:     filename,
+		dir:      filepath.Dir(filename),
+		textures: make(map[int]*Texture),
+		model:    &Model{sampler: DefaultSampler, materialMap: make(map[string]int)},
+	}
+	if err := l.load(data); err != nil {
+		return nil, err
+	}
+	l.model.warnings = l.warnings
+	return l.model, nil
+}
+
+// errorf creates an error of the gltf file
+func (l *gltfLoader) errorf(format string, args ...interface{}) *ParseError {
+	return &ParseError{File: l.file, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (l *gltfLoader) load(data []byte) error {
+	if len(data) >= 4 && binary.LittleEndian.Uint32(data) == glbMagic {
+		var err error
+		if data, l.bin, err = l.parseGLB(data); err != nil {
+			return err
+		}
+	}
+	if err := json.Unmarshal(data, &l.doc); err != nil {
+		return l.errorf("%v", err)
+	}
+	for _, ext := range l.doc.ExtensionsRequired {
+		if !gltfSupportedExtensions[ext] {
+			return l.errorf("required extension %q is not supported", ext)
+		}
+	}
+
+	l.buffers = make([][]byte, len(l.doc.Buffers))
+	for i := range l.doc.Buffers {
+		buf, err := l.loadBuffer(i)
+		if err != nil {
+			return err
+		}
+		l.buffers[i] = buf
+	}
+	for i := range l.doc.Materials {
+		mat, err := l.loadMaterial(i)
+		if err != nil {
+			return err
+		}
+		l.model.materialMap[mat.name] = mat.idx
+		l.model.materials = append(l.model.materials, mat)
+	}
+
+	var roots []int
+	if l.doc.Scene != nil || len(l.doc.Scenes) > 0 {
+		scene := 0
+		if l.doc.Scene != nil {
+			scene = *l.doc.Scene
+		}
+		if scene < 0 || scene >= len(l.doc.Scenes) {
+			return l.errorf("scene %d does not exist", scene)
+		}
+		roots = l.doc.Scenes[scene].Nodes
+	} else {
+		// without scenes, all nodes which are not children of another node are rendered
+		isChild := make([]bool, len(l.doc.Nodes))
+		for _, n := range l.doc.Nodes {
+			for _, c := range n.Children {
+				if c >= 0 && c < len(isChild) {
+					isChild[c] = true
+				}
+			}
+		}
+		for i := range l.doc.Nodes {
+			if !isChild[i] {
+				roots = append(roots, i)
+			}
+		}
+	}
+	visiting := make([]bool, len(l.doc.Nodes))
+	for _, n := range roots {
+		if err := l.addNode(n, m.IdentityMatrix(), visiting); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseGLB splits a glb file into its json and binary chunk
+// source: https://www.khronos.org/registry/glTF/specs/2.0/glTF-2.0.html#binary-gltf-layout
+func (l *gltfLoader) parseGLB(data []byte) ([]byte, []byte, error) {
+	if len(data) < 12 {
+		return nil, nil, l.errorf("glb header is truncated")
+	}
+	if version := binary.LittleEndian.Uint32(data[4:]); version != 2 {
+		return nil, nil, l.errorf("glb version %d is not supported", version)
+	}
+	length := int(binary.LittleEndian.Uint32(data[8:]))
+	if length < 12 || length > len(data) {
+		return nil, nil, l.errorf("glb file is truncated, expected %d bytes, got %d", length, len(data))
+	}
+	data = data[12:length]
+
+	var jsonChunk, binChunk []byte
+	for i := 0; len(data) > 0; i++ {
+		if len(data) < 8 {
+			return nil, nil, l.errorf("glb chunk header is truncated")
+		}
+		chunkLength := int(binary.LittleEndian.Uint32(data))
+		chunkType := binary.LittleEndian.Uint32(data[4:])
+		if chunkLength > len(data)-8 {
+			return nil, nil, l.errorf("glb chunk %d is truncated", i)
+		}
+		chunk := data[8 : 8+chunkLength]
+		data = data[8+chunkLength:]
+		switch {
+		case i == 0 && chunkType != glbChunkJSON:
+			return nil, nil, l.errorf("the first glb chunk must contain json")
+		case i == 0:
+			jsonChunk = chunk
+		case i == 1 && chunkType == glbChunkBIN:
+			binChunk = chunk
+		}
+		// other chunks are reserved for extensions and get ignored
+	}
+	if jsonChunk == nil {
+		return nil, nil, l.errorf("glb file has no json chunk")
+	}
+	return jsonChunk, binChunk, nil
+}
+
+// loadURI reads the data of an uri, which is either a base64 encoded data uri or a path relative to the gltf file
+func (l *gltfLoader) loadURI(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		idx := strings.Index(uri, ";base64,")
+		if idx == -1 {
+			return nil, l.errorf("data uri is not base64 encoded")
+		}
+		data, err := base64.StdEncoding.DecodeString(uri[idx+len(";base64,"):])
+		if err != nil {
+			return nil, l.errorf("data uri: %v", err)
+		}
+		return data, nil
+	}
+	path, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, l.errorf("invalid uri %q: %v", uri, err)
+	}
+	return ioutil.ReadFile(filepath.Join(l.dir, filepath.FromSlash(path)))
+}
+
+func (l *gltfLoader) loadBuffer(idx int) ([]byte, error) {
+	b := l.doc.Buffers[idx]
+	var data []byte
+	if b.URI == "" {
+		// the first buffer of a glb file without uri references the binary chunk
+		if idx != 0 || l.bin == nil {
+			return nil, l.errorf("buffer %d has no uri", idx)
+		}
+		data = l.bin
+	} else {
+		var err error
+		if data, err = l.loadURI(b.URI); err != nil {
+			return nil, err
+		}
+	}
+	if len(data) < b.ByteLength {
+		return nil, l.errorf("buffer %d is truncated, expected %d bytes, got %d", idx, b.ByteLength, len(data))
+	}
+	return data[:b.ByteLength], nil
+}
+
+func (l *gltfLoader) bufferView(idx int) ([]byte, int, error) {
+	if idx < 0 || idx >= len(l.doc.BufferViews) {
+		return nil, 0, l.errorf("buffer view %d does not exist", idx)
+	}
+	v := l.doc.BufferViews[idx]
+	if v.Buffer < 0 || v.Buffer >= len(l.buffers) {
+		return nil, 0, l.errorf("buffer %d of buffer view %d does not exist", v.Buffer, idx)
+	}
+	buf := l.buffers[v.Buffer]
+	if v.ByteOffset < 0 || v.ByteLength < 0 || v.ByteOffset > len(buf) || v.ByteLength > len(buf)-v.ByteOffset {
+		return nil, 0, l.errorf("buffer view %d exceeds its buffer", idx)
+	}
+	if v.ByteStride < 0 {
+		return nil, 0, l.errorf("buffer view %d has a negative byte stride %d", idx, v.ByteStride)
+	}
+	return buf[v.ByteOffset : v.ByteOffset+v.ByteLength], v.ByteStride, nil
+}
+
+// maxZeroAccessorCount limits the number of elements of accessors without buffer view, which are all zero
+const maxZeroAccessorCount = 1 << 24
+
+// readAccessor reads all elements of an accessor as floats, it returns the values and the number of components per element
+func (l *gltfLoader) readAccessor(idx int) ([]float64, int, error) {
+	if idx < 0 || idx >= len(l.doc.Accessors) {
+		return nil, 0, l.errorf("accessor %d does not exist", idx)
+	}
+	a := l.doc.Accessors[idx]
+	comps := map[string]int{"SCALAR": 1, "VEC2": 2, "VEC3": 3, "VEC4": 4, "MAT2": 4, "MAT3": 9, "MAT4": 16}[a.Type]
+	size := map[int]int{5120: 1, 5121: 1, 5122: 2, 5123: 2, 5125: 4, 5126: 4}[a.ComponentType]
+	if comps == 0 || size == 0 {
+		return nil, 0, l.errorf("accessor %d has an invalid type %s/%d", idx, a.Type, a.ComponentType)
+	}
+	if len(a.Sparse) > 0 {
+		return nil, 0, l.errorf("accessor %d: sparse accessors are not supported", idx)
+	}
+	if a.Count < 0 {
+		return nil, 0, l.errorf("accessor %d has a negative count %d", idx, a.Count)
+	}
+	if a.BufferView == nil {
+		// the elements are all zero, no buffer limits their number
+		if a.Count > maxZeroAccessorCount {
+			return nil, 0, l.errorf("accessor %d without buffer view has too many elements: %d", idx, a.Count)
+		}
+		return make([]float64, a.Count*comps), comps, nil
+	}
+	data, stride, err := l.bufferView(*a.BufferView)
+	if err != nil {
+		return nil, 0, err
+	}
+	elementSize := comps * size
+	if stride == 0 {
+		stride = elementSize
+	}
+	if stride < elementSize {
+		return nil, 0, l.errorf("accessor %d: the byte stride %d is smaller than its elements", idx, stride)
+	}
+	// the count is checked before the values are allocated, the division avoids overflows of huge counts
+	available := len(data) - a.ByteOffset
+	if a.ByteOffset < 0 || available < 0 || a.Count > 0 && (available < elementSize || a.Count-1 > (available-elementSize)/stride) {
+		return nil, 0, l.errorf("accessor %d exceeds its buffer view", idx)
+	}
+	ret := make([]float64, a.Count*comps)
+
+	for i := 0; i < a.Count; i++ {
+		for c := 0; c < comps; c++ {
+			p := data[a.ByteOffset+i*stride+c*size:]
+			var v float64
+			switch a.ComponentType {
+			case 5120:
+				v = float64(int8(p[0]))
+				if a.Normalized {
+					v = math.Max(v/127, -1)
+				}
+			case 5121:
+				v = float64(p[0])
+				if a.Normalized {
+					v /= 255
+				}
+			case 5122:
+				v = float64(int16(binary.LittleEndian.Uint16(p)))
+				if a.Normalized {
+					v = math.Max(v/32767, -1)
+				}
+			case 5123:
+				v = float64(binary.LittleEndian.Uint16(p))
+				if a.Normalized {
+					v /= 65535
+				}
+			case 5125:
+				v = float64(binary.LittleEndian.Uint32(p))
+			case 5126:
+				v = float64(math.Float32frombits(binary.LittleEndian.Uint32(p)))
+			}
+			ret[i*comps+c] = v
+		}
+	}
+	return ret, comps, nil
+}
+
+// texture loads the image referenced by a texture, textures which can not be loaded are reported as warnings
+func (l *gltfLoader) texture(info *gltfTextureInfo) (*textureMap, error) {
+	if info == nil {
+		return nil, nil
+	}
+	if info.Index < 0 || info.Index >= len(l.doc.Textures) {
+		return nil, l.errorf("texture %d does not exist", info.Index)
+	}
+	if info.TexCoord != 0 {
+		l.warn(l.errorf("texture %d uses texture coordinate set %d, only set 0 is supported", info.Index, info.TexCoord))
+	}
+	t := l.doc.Textures[info.Index]
+	if t.Source == nil {
+		l.warn(l.errorf("texture %d has no image", info.Index))
+		return nil, nil
+	}
+	tm := &textureMap{scale: m.Vector{X: 1, Y: 1, Z: 1, W: 1}, offset: m.Vector{W: 1}, bumpMultiplier: 1}
+	if info.Scale != nil {
+		tm.bumpMultiplier = *info.Scale
+	}
+	if t.Sampler != nil && *t.Sampler >= 0 && *t.Sampler < len(l.doc.Samplers) {
+		s := l.doc.Samplers[*t.Sampler]
+		tm.clamp = s.WrapS == gltfWrapClampToEdge && s.WrapT == gltfWrapClampToEdge
+	}
+
+	if tex, ok := l.textures[*t.Source]; ok {
+		if tex == nil {
+			return nil, nil
+		}
+		tm.tex = tex
+		return tm, nil
+	}
+	tex, err := l.loadImage(*t.Source)
+	if err != nil {
+		l.warn(l.errorf("texture %d ignored: %v", info.Index, err))
+		l.textures[*t.Source] = nil
+		return nil, nil
+	}
+	l.textures[*t.Source] = tex
+	tm.tex = tex
+	return tm, nil
+}
+
+func (l *gltfLoader) loadImage(idx int) (*Texture, error) {
+	if idx < 0 || idx >= len(l.doc.Images) {
+		return nil, fmt.Errorf("image %d does not exist", idx)
+	}
+	img := l.doc.Images[idx]
+	var data []byte
+	var err error
+	if img.BufferView != nil {
+		data, _, err = l.bufferView(*img.BufferView)
+	} else {
+		data, err = l.loadURI(img.URI)
+	}
+	if err != nil {
+		return nil, err
+	}
+	filename := ""
+	if img.URI != "" && !strings.HasPrefix(img.URI, "data:") {
+		filename = filepath.Join(l.dir, filepath.FromSlash(img.URI))
+	}
+	return decodeTexture(bytes.NewReader(data), filename)
+}
+
+func (l *gltfLoader) loadMaterial(idx int) (material, error) {
+	gm := l.doc.Materials[idx]
+	name := gm.Name
+	if name == "" {
+		name = fmt.Sprintf("material%d", idx)
+	}
+	mat := newMaterial(name)
+	mat.idx = idx
+	mat.pbr = true
+	mat.diffuseColor = m.Vector{X: 1, Y: 1, Z: 1, W: 1}
+	mat.metallic, mat.roughness = 1, 1
+	alpha := 1.
+
+	var err error
+	if pbr := gm.PbrMetallicRoughness; pbr != nil {
+		if len(pbr.BaseColorFactor) == 4 {
+			mat.diffuseColor = m.Vector{X: pbr.BaseColorFactor[0], Y: pbr.BaseColorFactor[1], Z: pbr.BaseColorFactor[2], W: 1}
+			alpha = pbr.BaseColorFactor[3]
+		}
+		if pbr.MetallicFactor != nil {
+			mat.metallic = *pbr.MetallicFactor
+		}
+		if pbr.RoughnessFactor != nil {
+			mat.roughness = *pbr.RoughnessFactor
+		}
+		if mat.mapKd, err = l.texture(pbr.BaseColorTexture); err != nil {
+			return mat, err
+		}
+		if mat.mapMetallicRoughness, err = l.texture(pbr.MetallicRoughnessTexture); err != nil {
+			return mat, err
+		}
+	}
+	if mat.normalMap, err = l.texture(gm.NormalTexture); err != nil {
+		return mat, err
+	}
+	if mat.mapKe, err = l.texture(gm.EmissiveTexture); err != nil {
+		return mat, err
+	}
+	if len(gm.EmissiveFactor) == 3 {
+		mat.emissiveColor = m.Vector{X: gm.EmissiveFactor[0], Y: gm.EmissiveFactor[1], Z: gm.EmissiveFactor[2], W: 1}
+	}
+	if gm.OcclusionTexture != nil {
+		l.warn(l.errorf("material %q: occlusion textures are not supported", name))
+	}
+	if _, ok := gm.Extensions["KHR_materials_unlit"]; ok {
+		mat.illum = illumColor
+	}
+
+	switch gm.AlphaMode {
+	case "", "OPAQUE":
+	case "MASK":
+		mat.dissolve = alpha
+		mat.alphaCutoff = 0.5
+		if gm.AlphaCutoff != nil {
+			mat.alphaCutoff = *gm.AlphaCutoff
+		}
+	case "BLEND":
+		mat.dissolve = alpha
+		mat.alphaBlend = true
+	default:
+		l.warn(l.errorf("material %q: unknown alpha mode %q", name, gm.AlphaMode))
+	}
+	return mat, nil
+}
+
+// addNode adds the meshes of a node and its children, transformed by the node hierarchy
+func (l *gltfLoader) addNode(idx int, parent m.Matrix, visiting []bool) error {
+	if idx < 0 || idx >= len(l.doc.Nodes) {
+		return l.errorf("node %d does not exist", idx)
+	}
+	if visiting[idx] {
+		return l.errorf("node %d is its own ancestor", idx)
+	}
+	visiting[idx] = true
+	defer func() { visiting[idx] = false }()
+
+	n := l.doc.Nodes[idx]
+	world := m.Multiply(parent, n.localMatrix())
+	if n.Mesh != nil {
+		if err := l.addMesh(idx, *n.Mesh, world); err != nil {
+			return err
+		}
+	}
+	for _, c := range n.Children {
+		if err := l.addNode(c, world, visiting); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// localMatrix returns the transformation of the node relative to its parent
+func (n *gltfNode) localMatrix() m.Matrix {
+	if len(n.Matrix) == 16 {
+		var ret m.Matrix
+		ret.FromArray(n.Matrix)
+		return ret
+	}
+	ret := m.IdentityMatrix()
+	if len(n.Translation) == 3 {
+		ret = m.Translate(ret, n.Translation[0], n.Translation[1], n.Translation[2])
+	}
+	if len(n.Rotation) == 4 {
+		q := m.Quaternion{X: n.Rotation[0], Y: n.Rotation[1], Z: n.Rotation[2], W: n.Rotation[3]}
+		ret = m.Multiply(ret, q.Matrix())
+	}
+	if len(n.Scale) == 3 {
+		ret = m.Scale(ret, n.Scale[0], n.Scale[1], n.Scale[2])
+	}
+	return ret
+}
+
+// addMesh adds the primitives of a mesh as a new group, named after the node and the mesh
+func (l *gltfLoader) addMesh(nodeIdx, meshIdx int, world m.Matrix) error {
+	if meshIdx < 0 || meshIdx >= len(l.doc.Meshes) {
+		return l.errorf("mesh %d does not exist", meshIdx)
+	}
+	mesh := l.doc.Meshes[meshIdx]
+	g := &Group{Name: mesh.Name, Object: l.doc.Nodes[nodeIdx].Name, Visible: true, model: l.model}
+	if g.Name == "" {
+		g.Name = fmt.Sprintf("mesh%d", meshIdx)
+	}
+	if g.Object == "" {
+		g.Object = fmt.Sprintf("node%d", nodeIdx)
+	}
+
+	// a negative determinant mirrors the mesh, which flips the winding order
+	normalMatrix := m.NormalMatrix(world)
+	mirrored := world.Determinant() < 0
+
+	for p, prim := range mesh.Primitives {
+		if err := l.addPrimitive(g, prim, world, normalMatrix, mirrored); err != nil {
+			return fmt.Errorf("mesh %d, primitive %d: %w", meshIdx, p, err)
+		}
+	}
+	if len(g.ranges) > 0 {
+		l.model.groups = append(l.model.groups, g)
+	}
+	return nil
+}
+
+func (l *gltfLoader) addPrimitive(g *Group, prim gltfPrimitive, world, normalMatrix m.Matrix, mirrored bool) error {
+	mode := gltfModeTriangles
+	if prim.Mode != nil {
+		mode = *prim.Mode
+	}
+	if mode != gltfModeTriangles && mode != gltfModeTriangleStrip && mode != gltfModeTriangleFan {
+		l.warn(l.errorf("primitive mode %d skipped, only triangles are supported", mode))
+		return nil
+	}
+	material := -1
+	if prim.Material != nil {
+		if *prim.Material < 0 || *prim.Material >= len(l.model.materials) {
+			return l.errorf("material %d does not exist", *prim.Material)
+		}
+		material = *prim.Material
+	}
+
+	posIdx, ok := prim.Attributes["POSITION"]
+	if !ok {
+		l.warn(l.errorf("primitive without positions skipped"))
+		return nil
+	}
+	positions, err := l.readAttribute(posIdx, 3)
+	if err != nil {
+		return err
+	}
+	count := len(positions) / 3
+	var normals, texCoords, tangents []float64
+	for name, attr := range map[string]struct {
+		dst   *[]float64
+		comps int
+	}{"NORMAL": {&normals, 3}, "TEXCOORD_0": {&texCoords, 2}, "TANGENT": {&tangents, 4}} {
+		idx, ok := prim.Attributes[name]
+		if !ok {
+			continue
+		}
+		if *attr.dst, err = l.readAttribute(idx, attr.comps); err != nil {
+			return err
+		}
+		if len(*attr.dst)/attr.comps != count {
+			return l.errorf("attribute %s has %d elements, expected %d", name, len(*attr.dst)/attr.comps, count)
+		}
+	}
+
+	o := l.model
+	vBase, nBase, tBase, tgBase := len(o.vertices), len(o.normals), len(o.texCoords), len(o.tangents)
+	for i := 0; i < count; i++ {
+		v := m.Vector{X: positions[i*3], Y: positions[i*3+1], Z: positions[i*3+2], W: 1}
+		o.vertices = append(o.vertices, m.Transform(world, v, false))
+	}
+	for i := 0; i < len(normals)/3; i++ {
+		n := m.Transform(normalMatrix, m.Vector{X: normals[i*3], Y: normals[i*3+1], Z: normals[i*3+2]}, true)
+		if m.Magnitude(n) > 0 {
+			n = m.Normalize(n)
+		}
+		n.W = 1
+		o.normals = append(o.normals, n)
+	}
+	for i := 0; i < len(texCoords)/2; i++ {
+		// gltf texture coordinates start at the top left corner
+		o.texCoords = append(o.texCoords, texCoord{s: texCoords[i*2], t: 1 - texCoords[i*2+1]})
+	}
+	for i := 0; i < len(tangents)/4; i++ {
+		t := m.Transform(world, m.Vector{X: tangents[i*4], Y: tangents[i*4+1], Z: tangents[i*4+2]}, true)
+		if m.Magnitude(t) > 0 {
+			t = m.Normalize(t)
+		}
+		// mirroring transformations flip the bitangent
+		t.W = tangents[i*4+3]
+		if mirrored {
+			t.W = -t.W
+		}
+		o.tangents = append(o.tangents, t)
+	}
+
+	var indices []int
+	if prim.Indices != nil {
+		values, _, err := l.readAccessor(*prim.Indices)
+		if err != nil {
+			return err
+		}
+		indices = make([]int, len(values))
+		for i, v := range values {
+			if v < 0 || int(v) >= count {
+				return l.errorf("index %v out of range, the primitive has %d vertices", v, count)
+			}
+			indices[i] = int(v)
+		}
+	} else {
+		indices = make([]int, count)
+		for i := range indices {
+			indices[i] = i
+		}
+	}
+
+	// source: https://www.khronos.org/registry/glTF/specs/2.0/glTF-2.0.html#topology-types
+	var tris [][3]int
+	switch mode {
+	case gltfModeTriangles:
+		for i := 0; i+2 < len(indices); i += 3 {
+			tris = append(tris, [3]int{indices[i], indices[i+1], indices[i+2]})
+		}
+	case gltfModeTriangleStrip:
+		for i := 0; i+2 < len(indices); i++ {
+			tris = append(tris, [3]int{indices[i], indices[i+1+i%2], indices[i+2-i%2]})
+		}
+	case gltfModeTriangleFan:
+		for i := 1; i+1 < len(indices); i++ {
+			tris = append(tris, [3]int{indices[i], indices[i+1], indices[0]})
+		}
+	}
+
+	for _, tri := range tris {
+		if mirrored {
+			tri[1], tri[2] = tri[2], tri[1]
+		}
+		f := face{material: material, hasNormals: normals != nil, hasTexture: texCoords != nil, hasTangents: tangents != nil && normals != nil}
+		for _, idx := range tri {
+			c := corner{v: vBase + idx, t: -1, n: -1, tg: -1}
+			if f.hasNormals {
+				c.n = nBase + idx
+			}
+			if f.hasTexture {
+				c.t = tBase + idx
+			}
+			if f.hasTangents {
+				c.tg = tgBase + idx
+			}
+			f.corners = append(f.corners, c)
+		}
+		firstTriangle := len(o.triangles)
+		o.addFace(f)
+		g.addFace(firstTriangle)
+	}
+	return nil
+}
+
+// readAttribute reads a vertex attribute and checks its number of components
+func (l *gltfLoader) readAttribute(idx, comps int) ([]float64, error) {
+	values, c, err := l.readAccessor(idx)
+	if err != nil {
+		return nil, err
+	}
+	if c != comps {
+		return nil, l.errorf("accessor %d has %d components, expected %d", idx, c, comps)
+	}
+	return values, nil
+}
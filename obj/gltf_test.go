@@ -0,0 +1,51 @@
+package obj
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// triangleGLTF is a triangle whose positions are read by the given accessor, the buffer holds 3 vec3 floats
+const triangleGLTF = `{
+	"asset": {"version": "2.0"},
+	"buffers": [{"byteLength": 36, "uri": "data:application/octet-stream;base64,AAAAAAAAAAAAAAAAAACAPwAAAAAAAAAAAAAAAAAAgD8AAAAA"}],
+	"bufferViews": [{"buffer": 0, "byteLength": 36, "byteStride": %d}],
+	"accessors": [%s],
+	"meshes": [{"primitives": [{"attributes": {"POSITION": 0}}]}],
+	"nodes": [{"mesh": 0}],
+	"scenes": [{"nodes": [0]}]
+}`
+
+func TestGLTFAccessorBounds(t *testing.T) {
+	tests := []struct {
+		name     string
+		stride   int
+		accessor string
+		err      string
+	}{
+		{"valid", 0, `{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"}`, ""},
+		{"valid with stride", 12, `{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"}`, ""},
+		{"negative count", 0, `{"bufferView": 0, "componentType": 5126, "count": -1, "type": "VEC3"}`, "negative count"},
+		{"count exceeds view", 0, `{"bufferView": 0, "componentType": 5126, "count": 4, "type": "VEC3"}`, "exceeds its buffer view"},
+		{"huge count", 0, `{"bufferView": 0, "componentType": 5126, "count": 4611686018427387904, "type": "VEC3"}`, "exceeds its buffer view"},
+		{"offset exceeds view", 0, `{"bufferView": 0, "byteOffset": 40, "componentType": 5126, "count": 1, "type": "VEC3"}`, "exceeds its buffer view"},
+		{"negative offset", 0, `{"bufferView": 0, "byteOffset": -4, "componentType": 5126, "count": 1, "type": "VEC3"}`, "exceeds its buffer view"},
+		{"stride smaller than element", 4, `{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"}`, "byte stride"},
+		{"negative stride", -12, `{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"}`, "negative byte stride"},
+		{"huge count without buffer view", 0, `{"componentType": 5126, "count": 4611686018427387904, "type": "VEC3"}`, "too many elements"},
+	}
+	for _, tt := range tests {
+		l := &gltfLoader{
+			textures: make(map[int]*Texture),
+			model:    &Model{sampler: DefaultSampler, materialMap: make(map[string]int)},
+		}
+		err := l.load([]byte(fmt.Sprintf(triangleGLTF, tt.stride, tt.accessor)))
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package obj
+
+import (
+	m "go-3d-rasterizer/math3d"
+	"go-3d-rasterizer/rasterizer"
+)
+
+// Group is a named part of a model, defined by the "o" and "g" directives of the obj file.
+// Faces before the first "o" or "g" directive belong to the group "default"
+type Group struct {
+	Name    string
+	Object  string // name of the enclosing object, empty if the file does not define objects
+	Visible bool
+
+	model  *Model
+	ranges []faceRange
+	bounds *m.AABB // nil until it has been calculated
+}
+
+// faceRange is a sequence of consecutive faces of a group which share the same material
+type faceRange struct {
+	material                    int
+	firstFace, lastFace         int // [firstFace, lastFace)
+	firstTriangle, lastTriangle int // [firstTriangle, lastTriangle)
+}
+
+// Groups returns all groups of the model, in the order in which they have been defined
+func (o *Model) Groups() []*Group {
+	return o.groups
+}
+
+// Group returns the first group with the given name, or nil if there is none
+func (o *Model) Group(name string) *Group {
+	for _, g := range o.groups {
+		if g.Name == name {
+			return g
+		}
+	}
+	return nil
+}
+
+// ShowAllGroups makes every group of the model visible
+func (o *Model) ShowAllGroups() {
+	for _, g := range o.groups {
+		g.Visible = true
+	}
+}
+
+// addGroup appends a new, visible group to the model
+func (o *Model) addGroup(object, name string) *Group {
+	g := &Group{Name: name, Object: object, Visible: true, model: o}
+	o.groups = append(o.groups, g)
+	return g
+}
+
+// addFace appends the last face and its triangles of the model to the group
+func (g *Group) addFace(firstTriangle int) {
+	faceIdx := len(g.model.faces) - 1
+	material := g.model.faces[faceIdx].material
+	if n := len(g.ranges); n > 0 {
+		last := &g.ranges[n-1]
+		if last.material == material && last.lastFace == faceIdx {
+			last.lastFace++
+			last.lastTriangle = len(g.model.triangles)
+			return
+		}
+	}
+	g.ranges = append(g.ranges, faceRange{
+		material:      material,
+		firstFace:     faceIdx,
+		lastFace:      faceIdx + 1,
+		firstTriangle: firstTriangle,
+		lastTriangle:  len(g.model.triangles),
+	})
+}
+
+// Materials returns the names of the materials used by the group, faces without material are not included
+func (g *Group) Materials() []string {
+	var ret []string
+	seen := make(map[int]bool)
+	for _, r := range g.ranges {
+		if r.material == -1 || seen[r.material] {
+			continue
+		}
+		seen[r.material] = true
+		ret = append(ret, g.model.materials[r.material].name)
+	}
+	return ret
+}
+
+// FaceCount returns the number of faces of the group
+func (g *Group) FaceCount() int {
+	count := 0
+	for _, r := range g.ranges {
+		count += r.lastFace - r.firstFace
+	}
+	return count
+}
+
+// TriangleCount returns the number of triangles of the group, after triangulation
+func (g *Group) TriangleCount() int {
+	count := 0
+	for _, r := range g.ranges {
+		count += r.lastTriangle - r.firstTriangle
+	}
+	return count
+}
+
+// BoundingBox returns the axis aligned bounding box of the vertices used by the group,
+// it is empty if the group has no faces. The box is calculated once and cached
+func (g *Group) BoundingBox() m.AABB {
+	if g.bounds == nil {
+		bb := m.EmptyAABB()
+		for _, r := range g.ranges {
+			for _, f := range g.model.faces[r.firstFace:r.lastFace] {
+				for _, c := range f.corners {
+					bb = bb.Extend(g.model.vertices[c.v])
+				}
+			}
+		}
+		g.bounds = &bb
+	}
+	return *g.bounds
+}
+
+// BoundingBox returns the axis aligned bounding box of all vertices of the model, it is calculated once and cached
+func (o *Model) BoundingBox() m.AABB {
+	if o.bounds == nil {
+		bb := m.NewAABB(o.vertices...)
+		o.bounds = &bb
+	}
+	return *o.bounds
+}
+
+// invalidateBounds discards the cached bounding boxes, it has to be called whenever vertices are moved
+func (o *Model) invalidateBounds() {
+	o.bounds = nil
+	for _, g := range o.groups {
+		g.bounds = nil
+	}
+}
+
+// frustumCull returns the groups whose bounding boxes are inside of the view frustum or intersect it,
+// groups without faces are kept because they are not drawn anyway
+func (o *Model) frustumCull(scene *rasterizer.Scene, groups []*Group) []*Group {
+	var ret []*Group
+	for _, g := range groups {
+		if len(g.ranges) == 0 || !scene.FrustumCull(g.BoundingBox()) {
+			ret = append(ret, g)
+		}
+	}
+	return ret
+}
+
+// visibleGroups returns the groups which are rendered by default
+func (o *Model) visibleGroups() []*Group {
+	var ret []*Group
+	for _, g := range o.groups {
+		if g.Visible {
+			ret = append(ret, g)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,352 @@
+package obj
+
+import (
+	m "go-3d-rasterizer/math3d"
+	"io"
+	"math"
+	"path"
+	"strconv"
+)
+
+// illumination models of the mtl format, only the ones which change the rendering are listed
+// source: http://paulbourke.net/dataformats/mtl/
+const (
+	illumColor             = 0 // color on, ambient off
+	illumAmbient           = 1 // color on, ambient on
+	illumHighlight         = 2 // highlight on
+	illumGlass             = 4 // transparency: glass on, reflection: ray trace on
+	illumRefractionFresnel = 6 // refraction on, fresnel off, ray trace on
+	illumRefractionGlass   = 7 // refraction on, fresnel on, ray trace on
+	illumShadowInvisible   = 9 // transparency: glass on, reflection: ray trace off
+)
+
+type material struct {
+	idx       int
+	name      string
+	mapKd     *textureMap
+	mapKs     *textureMap
+	mapD      *textureMap // alpha mask
+	bump      *textureMap // height map
+	normalMap *textureMap // tangent space normal map
+	mapKe     *textureMap
+
+	// metallic-roughness materials (gltf) use the diffuse color as base color
+	pbr                  bool
+	metallic             float64
+	roughness            float64
+	mapMetallicRoughness *textureMap // green channel is the roughness, blue channel the metalness
+	alphaCutoff          float64     // fragments with a lower alpha are discarded, 0 disables the alpha test
+	alphaBlend           bool        // blends with the alpha of the diffuse texture, even if the material is opaque
+
+	ambientColor     m.Vector
+	diffuseColor     m.Vector
+	specularColor    m.Vector
+	emissiveColor    m.Vector
+	specularExponent float64
+	dissolve         float64 // opacity, 1 is opaque
+	opticalDensity   float64 // index of refraction
+	illum            int
+}
+
+func newMaterial(name string) material {
+	return material{name: name, dissolve: 1, opticalDensity: 1, illum: illumHighlight}
+}
+
+// defaultMaterial creates a gray material, which makes the lighting visible
+func defaultMaterial(name string) material {
+	mat := newMaterial(name)
+	mat.ambientColor = m.Vector{X: 0.1, Y: 0.1, Z: 0.1, W: 1}
+	mat.diffuseColor = m.Vector{X: 0.8, Y: 0.8, Z: 0.8, W: 1}
+	mat.specularColor = m.Vector{X: 0.2, Y: 0.2, Z: 0.2, W: 1}
+	mat.specularExponent = 20
+	return mat
+}
+
+// addDefaultMaterial adds the default material for formats without materials, it returns the index of the material
+func (o *Model) addDefaultMaterial() int {
+	mat := defaultMaterial("default")
+	mat.idx = len(o.materials)
+	o.materials = append(o.materials, mat)
+	o.materialMap[mat.name] = mat.idx
+	return mat.idx
+}
+
+// isTransparent reports whether the material needs to be blended with the frame buffer, alpha tested materials are not blended
+func (mat *material) isTransparent() bool {
+	return mat.alphaCutoff == 0 && (mat.dissolve < 1 || mat.mapD != nil || mat.alphaBlend || mat.isGlass())
+}
+
+// isGlass reports whether the illumination model makes the material more opaque at grazing angles
+func (mat *material) isGlass() bool {
+	switch mat.illum {
+	case illumGlass, illumRefractionFresnel, illumRefractionGlass, illumShadowInvisible:
+		return mat.opticalDensity > 1
+	}
+	return false
+}
+
+// metallicRoughness converts the metallic-roughness parameters into the diffuse color, specular color and
+// specular exponent of the blinn-phong lighting model
+// source: https://www.khronos.org/registry/glTF/specs/2.0/glTF-2.0.html#appendix-b-brdf-implementation
+func (mat *material) metallicRoughness(sm Sampler, tl texLookup, textured bool) (m.Vector, m.Vector, float64) {
+	metallic, roughness := mat.metallic, mat.roughness
+	if textured && mat.mapMetallicRoughness != nil {
+		c := mat.mapMetallicRoughness.sample(sm, tl)
+		roughness *= c.Y
+		metallic *= c.Z
+	}
+	dielectric := m.Vector{X: 0.04, Y: 0.04, Z: 0.04, W: 1}
+	diffuse := m.Mul(mat.diffuseColor, 1-metallic)
+	specular := m.Lerp(dielectric, mat.diffuseColor, metallic)
+	// blinn-phong exponent which matches the width of the ggx highlight with alpha = roughness²
+	alpha := math.Max(roughness*roughness, 0.01)
+	return diffuse, specular, 2/(alpha*alpha) - 2
+}
+
+// textureMap is a texture referenced by a material, together with the options of the map statement
+type textureMap struct {
+	tex            *Texture
+	scale          m.Vector // -s
+	offset         m.Vector // -o
+	clamp          bool     // -clamp
+	bumpMultiplier float64  // -bm
+}
+
+// texLookup holds a texture coordinate and its screen space derivatives
+type texLookup struct {
+	s, t                   float64
+	dsdx, dtdx, dsdy, dtdy float64
+}
+
+// sample reads the filtered color of the texture map, the texture coordinate and its derivatives get scaled and offset
+func (tm *textureMap) sample(sm Sampler, tl texLookup) m.Vector {
+	if tm.clamp {
+		sm.Wrap = WrapClamp
+	}
+	s := tl.s*tm.scale.X + tm.offset.X
+	t := tl.t*tm.scale.Y + tm.offset.Y
+	return sm.SampleGrad(tm.tex, s, t, tl.dsdx*tm.scale.X, tl.dtdx*tm.scale.Y, tl.dsdy*tm.scale.X, tl.dtdy*tm.scale.Y)
+}
+
+// scalar reads a single channel of the texture map, which is the alpha channel for textures
+// with transparent pixels and the luminance otherwise
+func (tm *textureMap) scalar(sm Sampler, tl texLookup) float64 {
+	c := tm.sample(sm, tl)
+	if !tm.tex.opaque {
+		return c.W
+	}
+	return 0.2126*c.X + 0.7152*c.Y + 0.0722*c.Z
+}
+
+// number of arguments of the texture options which are parsed but not used
+var ignoredTextureOptions = map[string]int{
+	"-blendu":  1,
+	"-blendv":  1,
+	"-boost":   1,
+	"-cc":      1,
+	"-imfchan": 1,
+	"-mm":      2,
+	"-texres":  1,
+	"-type":    1,
+}
+
+// parseTextureMap parses the options and the filename of a map statement and loads the texture with the resolver,
+// dir is the directory of the material library relative to the model
+// syntax: map_Kd [-s u v w] [-o u v w] [-clamp on|off] [-bm mult] filename
+func parseTextureMap(t *tokenizer, d *diagnostics, res Resolver, dir string) (*textureMap, error) {
+	tm := &textureMap{scale: m.Vector{X: 1, Y: 1, Z: 1, W: 1}, offset: m.Vector{W: 1}, bumpMultiplier: 1}
+	i := 1
+	for i < len(t.tokens) && len(t.tokens[i].text) > 1 && t.tokens[i].text[0] == '-' {
+		opt := t.tokens[i]
+		i++
+		switch opt.text {
+		case "-s", "-o", "-t":
+			// 1 to 3 numbers, the missing ones keep their default
+			var v []float64
+			for ; i < len(t.tokens) && len(v) < 3; i++ {
+				f, err := strconv.ParseFloat(t.tokens[i].text, 64)
+				if err != nil {
+					break
+				}
+				v = append(v, f)
+			}
+			if len(v) == 0 {
+				return nil, d.report(t.errorAt(opt, "texture option %q expects numbers", opt.text))
+			}
+			if opt.text == "-t" {
+				d.warn(t.errorAt(opt, "unsupported texture option %q skipped", opt.text))
+				continue
+			}
+			target := &tm.scale
+			if opt.text == "-o" {
+				target = &tm.offset
+			}
+			target.X = v[0]
+			if len(v) > 1 {
+				target.Y = v[1]
+			}
+			if len(v) > 2 {
+				target.Z = v[2]
+			}
+		case "-clamp":
+			if i >= len(t.tokens) || (t.tokens[i].text != "on" && t.tokens[i].text != "off") {
+				return nil, d.report(t.errorAt(opt, "texture option -clamp expects on or off"))
+			}
+			tm.clamp = t.tokens[i].text == "on"
+			i++
+		case "-bm":
+			if i >= len(t.tokens) {
+				return nil, d.report(t.errorAt(opt, "texture option -bm expects a number"))
+			}
+			v, err := strconv.ParseFloat(t.tokens[i].text, 64)
+			if err != nil {
+				return nil, d.report(t.errorAt(t.tokens[i], "invalid number %q", t.tokens[i].text))
+			}
+			tm.bumpMultiplier = v
+			i++
+		default:
+			count, ok := ignoredTextureOptions[opt.text]
+			if !ok {
+				return nil, d.report(t.errorAt(opt, "unknown texture option %q", opt.text))
+			}
+			d.warn(t.errorAt(opt, "unsupported texture option %q skipped", opt.text))
+			i += count
+		}
+	}
+	if i >= len(t.tokens) {
+		return nil, d.report(t.errorAt(t.tokens[0], "%q expects a filename", t.tokens[0].text))
+	}
+
+	tex, err := resolveTexture(res, path.Join(dir, t.rest(i)))
+	if err != nil {
+		d.warn(t.errorAt(t.tokens[i], "texture ignored: %v", err))
+		return nil, nil
+	}
+	tm.tex = tex
+	return tm, nil
+}
+
+// parseMaterial parses an .mtl file, filename is used to report problems. The textures are opened with the resolver,
+// relative to the directory of name which is the name of the material library within the resolver.
+// bumpAsNormalMap loads bump maps as normal maps, see ParseOptions
+func parseMaterial(r io.Reader, filename, name string, d *diagnostics, res Resolver, bumpAsNormalMap bool) ([]material, error) {
+	var ret []material
+	t := newTokenizer(r, filename)
+	for t.next() {
+		directive := t.tokens[0]
+		args := len(t.tokens) - 1
+		if directive.text == "newmtl" {
+			if args < 1 {
+				if err := d.report(t.errorAt(directive, "newmtl expects a name")); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			ret = append(ret, newMaterial(t.rest(1)))
+			continue
+		}
+		if len(ret) == 0 {
+			d.warn(t.errorAt(directive, "%q outside of a material skipped", directive.text))
+			continue
+		}
+		mat := &ret[len(ret)-1]
+
+		switch directive.text {
+		case "Ka", "Kd", "Ks", "Ke":
+			// a single value is used for all channels
+			if args != 1 && args != 3 {
+				if err := d.report(t.errorAt(directive, "%q expects 1 or 3 arguments, got %d", directive.text, args)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			v, err := t.parseFloats(d, 1, args)
+			if err != nil {
+				return nil, err
+			}
+			if args == 1 {
+				v = []float64{v[0], v[0], v[0]}
+			}
+			col := m.Vector{X: v[0], Y: v[1], Z: v[2], W: 1}
+			switch directive.text {
+			case "Ka":
+				mat.ambientColor = col
+			case "Kd":
+				mat.diffuseColor = col
+			case "Ks":
+				mat.specularColor = col
+			default:
+				mat.emissiveColor = col
+			}
+		case "Ns", "Ni", "d", "Tr":
+			if args != 1 {
+				if err := d.report(t.errorAt(directive, "%q expects 1 argument, got %d", directive.text, args)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			v, err := t.parseFloats(d, 1, 1)
+			if err != nil {
+				return nil, err
+			}
+			switch directive.text {
+			case "Ns":
+				mat.specularExponent = v[0]
+			case "Ni":
+				mat.opticalDensity = v[0]
+			case "d":
+				mat.dissolve = math.Max(0, math.Min(1, v[0]))
+			default:
+				mat.dissolve = math.Max(0, math.Min(1, 1-v[0]))
+			}
+		case "illum":
+			if args != 1 {
+				if err := d.report(t.errorAt(directive, "illum expects 1 argument, got %d", args)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			illum, err := strconv.Atoi(t.tokens[1].text)
+			if err != nil || illum < 0 || illum > 10 {
+				if err := d.report(t.errorAt(t.tokens[1], "invalid illumination model %q", t.tokens[1].text)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			mat.illum = illum
+		case "map_Kd", "map_Ks", "map_Ke", "map_d", "map_Bump", "map_bump", "bump", "norm":
+			tm, err := parseTextureMap(t, d, res, path.Dir(name))
+			if err != nil {
+				return nil, err
+			}
+			if tm == nil {
+				continue
+			}
+			switch directive.text {
+			case "map_Kd":
+				mat.mapKd = tm
+			case "map_Ks":
+				mat.mapKs = tm
+			case "map_Ke":
+				mat.mapKe = tm
+			case "map_d":
+				mat.mapD = tm
+			case "norm":
+				mat.normalMap = tm
+			default:
+				if bumpAsNormalMap {
+					mat.normalMap = tm
+				} else {
+					mat.bump = tm
+				}
+			}
+		default:
+			d.warn(t.errorAt(directive, "unsupported directive %q skipped", directive.text))
+		}
+	}
+
+	if err := t.err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,48 @@
+package obj
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseBumpMaps(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name            string
+		line            string
+		bumpAsNormalMap bool
+		normal          bool
+	}{
+		{"bump", "bump map.png", false, false},
+		{"bump with multiplier", "bump -bm 2 map.png", false, false},
+		{"map_Bump", "map_Bump map.png", false, false},
+		{"map_Bump with multiplier", "map_Bump -bm 0.5 map.png", false, false},
+		{"norm", "norm map.png", false, true},
+		{"bump as normal map", "bump map.png", true, true},
+		{"map_Bump as normal map", "map_Bump -bm 0.5 map.png", true, true},
+		{"norm with bump as normal map", "norm map.png", true, true},
+	}
+	for _, tt := range tests {
+		fsys := fstest.MapFS{
+			"model.mtl": {Data: []byte("newmtl a\n" + tt.line + "\n")},
+			"map.png":   {Data: buf.Bytes()},
+		}
+		opts := ParseOptions{Strict: true, Resolver: FSResolver(fsys, "."), BumpAsNormalMap: tt.bumpAsNormalMap}
+		model, err := Parse(strings.NewReader("mtllib model.mtl\n"), opts)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		mat := model.materials[0]
+		if (mat.normalMap != nil) != tt.normal || (mat.bump != nil) == tt.normal {
+			t.Errorf("%s: normal map %v, bump map %v, want normal map %v", tt.name, mat.normalMap != nil, mat.bump != nil, tt.normal)
+		}
+	}
+}
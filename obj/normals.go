@@ -0,0 +1,127 @@
+package obj
+
+import (
+	m "go-3d-rasterizer/math3d"
+	"math"
+)
+
+// NormalMode selects how GenerateNormals calculates the vertex normals
+type NormalMode int
+
+// normal modes
+const (
+	// NormalsFlat uses the face normal for every corner of a face
+	NormalsFlat NormalMode = iota
+	// NormalsSmoothArea averages the normals of the adjacent faces, weighted by their area
+	NormalsSmoothArea
+	// NormalsSmoothAngle averages the normals of the adjacent faces, weighted by the angle of the face at the vertex
+	NormalsSmoothAngle
+)
+
+// HasNormals reports whether every face of the model has vertex normals
+func (o *Model) HasNormals() bool {
+	for _, f := range o.faces {
+		if !f.hasNormals {
+			return false
+		}
+	}
+	return len(o.faces) > 0
+}
+
+// GenerateNormals replaces the normals of the model with calculated ones.
+// Smooth normals are only averaged across faces of the same smoothing group (OBJ "s" directive),
+// faces with smoothing turned off ("s off" or "s 0") are shaded flat. Files without any smoothing groups are smoothed entirely.
+// If creaseAngle (in degrees) is greater than 0, faces whose normals differ by more than the crease angle are not smoothed together.
+// Existing tangents are removed, as they depend on the normals
+func (o *Model) GenerateNormals(mode NormalMode, creaseAngle float64) {
+	faceNormals := make([]m.Vector, len(o.faces))
+	faceAreas := make([]float64, len(o.faces))
+	for i, f := range o.faces {
+		faceNormals[i], faceAreas[i] = o.faceNormal(f)
+	}
+
+	// faces adjacent to each vertex
+	type faceCorner struct{ face, corner int }
+	adjacent := make([][]faceCorner, len(o.vertices))
+	if mode != NormalsFlat {
+		for i, f := range o.faces {
+			for c, fc := range f.corners {
+				adjacent[fc.v] = append(adjacent[fc.v], faceCorner{i, c})
+			}
+		}
+	}
+
+	minCos := -1.
+	if creaseAngle > 0 {
+		minCos = math.Cos(creaseAngle * math.Pi / 180.)
+	}
+
+	o.normals = o.normals[:0]
+	o.tangents = o.tangents[:0]
+	for i := range o.faces {
+		f := &o.faces[i]
+		for c := range f.corners {
+			normal := faceNormals[i]
+			if mode != NormalsFlat && o.isSmooth(f.smoothingGroup) {
+				sum := m.Vector{}
+				for _, adj := range adjacent[f.corners[c].v] {
+					other := o.faces[adj.face]
+					if adj.face != i && (other.smoothingGroup != f.smoothingGroup || m.Dot(faceNormals[adj.face], faceNormals[i]) < minCos) {
+						continue
+					}
+					weight := faceAreas[adj.face]
+					if mode == NormalsSmoothAngle {
+						weight = o.cornerAngle(other, adj.corner)
+					}
+					sum = m.Add(sum, m.Mul(faceNormals[adj.face], weight))
+				}
+				if m.Magnitude(sum) > 0 {
+					normal = m.Normalize(sum)
+				}
+			}
+			f.corners[c].n = len(o.normals)
+			o.normals = append(o.normals, normal)
+		}
+		f.hasNormals = true
+		f.hasTangents = false
+	}
+}
+
+// isSmooth reports whether faces of the given smoothing group get smooth normals
+func (o *Model) isSmooth(smoothingGroup int) bool {
+	return !o.hasSmoothingGroups || smoothingGroup != 0
+}
+
+// faceNormal calculates the normal and the area of a face, using newell's method which also works for non planar polygons
+// source: https://www.khronos.org/opengl/wiki/Calculating_a_Surface_Normal
+func (o *Model) faceNormal(f face) (m.Vector, float64) {
+	n := m.Vector{}
+	count := len(f.corners)
+	for c := 0; c < count; c++ {
+		a, b := o.vertices[f.corners[c].v], o.vertices[f.corners[(c+1)%count].v]
+		n.X += (a.Y - b.Y) * (a.Z + b.Z)
+		n.Y += (a.Z - b.Z) * (a.X + b.X)
+		n.Z += (a.X - b.X) * (a.Y + b.Y)
+	}
+	area := m.Magnitude(n) / 2.
+	if area == 0 {
+		return m.Vector{X: 0, Y: 0, Z: 0, W: 1}, 0
+	}
+	n = m.Normalize(n)
+	n.W = 1
+	return n, area
+}
+
+// cornerAngle calculates the interior angle of a face at the given corner
+func (o *Model) cornerAngle(f face, corner int) float64 {
+	count := len(f.corners)
+	v := f.corners[corner].v
+	prev := f.corners[(corner+count-1)%count].v
+	next := f.corners[(corner+1)%count].v
+	a := m.Sub(o.vertices[prev], o.vertices[v])
+	b := m.Sub(o.vertices[next], o.vertices[v])
+	if m.Magnitude(a) == 0 || m.Magnitude(b) == 0 {
+		return 0
+	}
+	return math.Acos(math.Max(-1, math.Min(1, m.Dot(m.Normalize(a), m.Normalize(b)))))
+}
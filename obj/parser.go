@@ -1,27 +1,36 @@
 package obj
 
 import (
-	"bufio"
 	"go-3d-rasterizer/math3d"
 	m "go-3d-rasterizer/math3d"
+	"io"
+	"io/fs"
 	"math"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
-
-	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
 // Model holds the wavefront obj model data
 type Model struct {
 	vertices    []m.Vector
 	normals     []m.Vector
+	tangents    []m.Vector // W is the sign of the bitangent
 	texCoords   []texCoord
+	colors      []m.Vector // per vertex, empty if the file has no vertex colors
 	materials   []material
-	materialMap map[string]*material
+	materialMap map[string]int
+	sampler     Sampler
+	warnings    []*ParseError
 
-	triangles []indices
+	faces              []face
+	triangles          []triangle
+	groups             []*Group
+	hasSmoothingGroups bool
+	pointSize          int     // size in pixels of the vertices of point clouds
+	bounds             *m.AABB // bounding box of the vertices, nil until it has been calculated
 }
 
 type texCoord struct {
@@ -29,194 +38,406 @@ type texCoord struct {
 	t float64
 }
 
-type indices struct {
-	v0, v1, v2, v3 int
-	n0, n1, n2, n3 int
-	t0, t1, t2, t3 int
+// corner references the vertex, texture coordinate, normal and tangent of a face corner, -1 if they are missing
+type corner struct {
+	v, t, n, tg int
+}
+
+// face is a polygon with an arbitrary number of corners, as it has been defined in the file
+type face struct {
+	corners        []corner
 	material       int
+	smoothingGroup int // 0 means smoothing is turned off
 	hasNormals     bool
 	hasTexture     bool
-	hasFour        bool
+	hasTangents    bool
+}
+
+// triangle is a part of a triangulated face, it references three corners of the face
+type triangle struct {
+	face    int
+	corners [3]int
 }
 
-type material struct {
-	idx   int
-	name  string
-	mapKd string
-	mapKs string
+// ParseFile lodds & parses an .obj file, problems which do not prevent the model from being loaded are reported as warnings
+func ParseFile(filename string) (*Model, error) {
+	return ParseFileWithOptions(filename, ParseOptions{})
+}
 
-	ambientColor     m.Vector
-	diffuseColor     m.Vector
-	specularColor    m.Vector
-	specularExponent float64
+// ParseFileWithOptions lodds & parses an .obj file, without a resolver in the options
+// the referenced files are opened relative to the directory of the file
+func ParseFileWithOptions(filename string, opts ParseOptions) (*Model, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
 
-	mapKdData   []rl.Color
-	mapKdWidth  int
-	mapKdHeight int
+	if opts.Resolver == nil {
+		opts.Resolver = DirResolver(filepath.Dir(filename))
+	}
+	return parse(file, filename, opts)
+}
 
-	mapKsData   []rl.Color
-	mapKsWidth  int
-	mapKsHeight int
+// Parse parses an .obj model from a reader, material libraries and textures are opened with the resolver of the options.
+// Without a resolver they are reported as missing
+func Parse(r io.Reader, opts ParseOptions) (*Model, error) {
+	return parse(r, "", opts)
 }
 
-// ParseFile lodds & parses an .obj file
-func ParseFile(filename string) (*Model, error) {
-	file, err := os.Open(filename)
+// ParseFS parses an .obj file of a file system, without a resolver in the options
+// the referenced files are opened relative to the directory of the file within fsys
+func ParseFS(fsys fs.FS, name string, opts ParseOptions) (*Model, error) {
+	file, err := fsys.Open(name)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	ret := &Model{}
-	ret.materialMap = make(map[string]*material)
-	matIdx := -1
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		parts := strings.Split(strings.Trim(scanner.Text(), " "), " ")
-		if len(parts) == 2 {
-			if parts[0] == "mtllib" {
-				mats, err := parseMaterial(filepath.Join(filepath.Dir(filename), parts[1]))
-				if err != nil {
-					return nil, err
-				}
-				for i, m := range mats {
-					m.idx = len(ret.materials) + i
-				}
-				ret.materials = append(ret.materials, mats...)
-				for i, m := range mats {
-					ret.materialMap[m.name] = &ret.materials[i]
-				}
-			} else if parts[0] == "usemtl" {
-				matIdx = ret.materialMap[parts[1]].idx
-			}
+	if opts.Resolver == nil {
+		opts.Resolver = FSResolver(fsys, path.Dir(name))
+	}
+	return parse(file, name, opts)
+}
+
+// parse parses an .obj model, filename is only used to report problems
+func parse(r io.Reader, filename string, opts ParseOptions) (*Model, error) {
+	p := &objParser{
+		model:           &Model{sampler: DefaultSampler, materialMap: make(map[string]int)},
+		tokenizer:       newTokenizer(r, filename),
+		diagnostics:     diagnostics{strict: opts.Strict},
+		resolver:        opts.Resolver,
+		bumpAsNormalMap: opts.BumpAsNormalMap,
+		matIdx:          -1,
+		groupName:       "default",
+		groups:          make(map[[2]string]*Group),
+	}
+	if p.resolver == nil {
+		p.resolver = noResolver{}
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	p.model.warnings = p.warnings
+	return p.model, nil
+}
+
+// LoadFile loads a model, the file format is chosen by the file extension: .gltf and .glb files are loaded with ParseGLTF,
+// .stl files with ParseSTL, .ply files with ParsePLY and all other files are parsed as .obj files
+func LoadFile(filename string) (*Model, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".gltf", ".glb":
+		return ParseGLTF(filename)
+	case ".stl":
+		return ParseSTL(filename)
+	case ".ply":
+		return ParsePLY(filename)
+	}
+	return ParseFile(filename)
+}
+
+// Warnings returns the problems which have been found while the model was parsed
+func (o *Model) Warnings() []*ParseError {
+	return o.warnings
+}
+
+// objParser holds the state of the .obj parser
+type objParser struct {
+	*tokenizer
+	diagnostics
+	model           *Model
+	resolver        Resolver
+	matIdx          int
+	bumpAsNormalMap bool
+	smoothingGroup  int
+
+	object    string
+	groupName string
+	group     *Group               // nil until the first face of the current group
+	groups    map[[2]string]*Group // groups by object and name, groups may be continued later in the file
+}
+
+func (p *objParser) parse() error {
+	for p.next() {
+		directive := p.tokens[0]
+		var err error
+		switch directive.text {
+		case "v":
+			err = p.parseVertex()
+		case "vn":
+			err = p.parseNormal()
+		case "vt":
+			err = p.parseTexCoord()
+		case "f":
+			err = p.parseFace()
+		case "mtllib":
+			err = p.parseMaterialLibs()
+		case "usemtl":
+			err = p.parseUseMaterial()
+		case "s":
+			err = p.parseSmoothingGroup()
+		case "o", "g":
+			err = p.parseGroup()
+		default:
+			p.warn(p.errorAt(directive, "unsupported directive %q skipped", directive.text))
 		}
-		if len(parts) >= 4 && len(parts) <= 5 && parts[0] == "v" {
-			x, _ := strconv.ParseFloat(parts[1], 32)
-			y, _ := strconv.ParseFloat(parts[2], 32)
-			z, _ := strconv.ParseFloat(parts[3], 32)
-			w := 1.
-			if len(parts) == 5 {
-				w, _ = strconv.ParseFloat(parts[4], 32)
+		if err != nil {
+			return err
+		}
+	}
+	return p.err()
+}
+
+// expectArgs checks the number of arguments of the current directive, lines with a wrong number are reported and skipped
+func (p *objParser) expectArgs(min, max int) (bool, error) {
+	args := len(p.tokens) - 1
+	if args >= min && args <= max {
+		return true, nil
+	}
+	tok := p.tokens[0]
+	if args > max {
+		tok = p.tokens[max+1]
+	}
+	return false, p.report(p.errorAt(tok, "%q expects %d to %d arguments, got %d", p.tokens[0].text, min, max, args))
+}
+
+// parseVertex parses "v x y z [w]", vertex colors are supported as an extension: "v x y z [w] r g b"
+func (p *objParser) parseVertex() error {
+	if ok, err := p.expectArgs(3, 7); !ok {
+		return err
+	}
+	args := len(p.tokens) - 1
+	if args == 5 {
+		return p.report(p.errorAt(p.tokens[5], "%q expects 3, 4, 6 or 7 arguments, got %d", p.tokens[0].text, args))
+	}
+	v, err := p.parseFloats(&p.diagnostics, 1, 3)
+	if err != nil {
+		return err
+	}
+	w := 1.
+	if args == 4 || args == 7 {
+		// an invalid w would break the homogeneous divide, it falls back to the default instead of 0
+		tok := p.tokens[4]
+		if w, err = strconv.ParseFloat(tok.text, 64); err != nil {
+			w = 1
+			if err := p.report(p.errorAt(tok, "invalid w %q, using 1", tok.text)); err != nil {
+				return err
 			}
-			ret.vertices = append(ret.vertices, m.Vector{X: x, Y: y, Z: z, W: w})
 		}
-		if len(parts) == 4 && parts[0] == "vn" {
-			x, _ := strconv.ParseFloat(parts[1], 32)
-			y, _ := strconv.ParseFloat(parts[2], 32)
-			z, _ := strconv.ParseFloat(parts[3], 32)
-			ret.normals = append(ret.normals, m.Normalize(m.Vector{X: x, Y: y, Z: z, W: 1}))
+	}
+	p.model.vertices = append(p.model.vertices, m.Vector{X: v[0], Y: v[1], Z: v[2], W: w})
+
+	// the colors are per vertex, vertices without a color are white
+	white := m.Vector{X: 1, Y: 1, Z: 1, W: 1}
+	if args >= 6 {
+		for len(p.model.colors) < len(p.model.vertices)-1 {
+			p.model.colors = append(p.model.colors, white)
 		}
-		if len(parts) >= 3 && len(parts) <= 4 && parts[0] == "vt" { // ignore 3rd parameter
-			s, _ := strconv.ParseFloat(parts[1], 32)
-			t, _ := strconv.ParseFloat(parts[2], 32)
-			ret.texCoords = append(ret.texCoords, texCoord{s: s, t: t})
+		c, err := p.parseFloats(&p.diagnostics, args-2, 3)
+		if err != nil {
+			return err
 		}
-		if len(parts) >= 4 && len(parts) <= 5 && parts[0] == "f" {
-			subParts1 := strings.Split(parts[1], "/")
-			subParts2 := strings.Split(parts[2], "/")
-			subParts3 := strings.Split(parts[3], "/")
-			if len(subParts2) != len(subParts1) || len(subParts3) != len(subParts1) {
-				continue // faullty format according to spec, skip
-			}
-			var subParts4 []string
-			hasFour := len(parts) == 5
-			if hasFour {
-				subParts4 = strings.Split(parts[4], "/")
-			}
+		p.model.colors = append(p.model.colors, m.Vector{X: c[0], Y: c[1], Z: c[2], W: 1})
+	} else if len(p.model.colors) > 0 {
+		p.model.colors = append(p.model.colors, white)
+	}
+	return nil
+}
 
-			// cases
-			//		1			v
-			//		1/2			v/t
-			//		1/2/3		v/t/n
-			//		1//3 		v//n
-			v0, _ := strconv.Atoi(subParts1[0])
-			v1, _ := strconv.Atoi(subParts2[0])
-			v2, _ := strconv.Atoi(subParts3[0])
-			v3 := 0
-			if hasFour {
-				v3, _ = strconv.Atoi(subParts4[0])
-			}
-			t0, t1, t2, t3 := 0, 0, 0, 0
-			hasTexture := false
-			if len(subParts1) > 1 {
-				hasTexture = len(subParts1[1]) != 0
-				if hasTexture {
-					t0, _ = strconv.Atoi(subParts1[1])
-					t1, _ = strconv.Atoi(subParts2[1])
-					t2, _ = strconv.Atoi(subParts3[1])
-					if hasFour {
-						t3, _ = strconv.Atoi(subParts4[1])
-					}
-				}
-			}
-			n0, n1, n2, n3 := 0, 0, 0, 0
-			hasNormals := false
-			if len(subParts1) == 3 {
-				hasNormals = true
-				n0, _ = strconv.Atoi(subParts1[2])
-				n1, _ = strconv.Atoi(subParts2[2])
-				n2, _ = strconv.Atoi(subParts3[2])
-				if hasFour {
-					n3, _ = strconv.Atoi(subParts4[2])
-				}
-			}
+func (p *objParser) parseNormal() error {
+	if ok, err := p.expectArgs(3, 3); !ok {
+		return err
+	}
+	v, err := p.parseFloats(&p.diagnostics, 1, 3)
+	if err != nil {
+		return err
+	}
+	n := m.Vector{X: v[0], Y: v[1], Z: v[2], W: 1}
+	// normals which are already normalized are kept as they are, so written models are read back unchanged
+	if math.Abs(m.Magnitude(n)-1) > 1e-12 {
+		n = m.Normalize(n)
+	}
+	p.model.normals = append(p.model.normals, n)
+	return nil
+}
 
-			if v0 < 0 {
-				v0 = len(ret.vertices) + v0
-			}
-			if v1 < 0 {
-				v1 = len(ret.vertices) + v1
-			}
-			if v2 < 0 {
-				v2 = len(ret.vertices) + v2
-			}
-			if hasFour && v3 < 0 {
-				v3 = len(ret.vertices) + v3
-			}
+func (p *objParser) parseTexCoord() error {
+	if ok, err := p.expectArgs(1, 3); !ok {
+		return err
+	}
+	v, err := p.parseFloats(&p.diagnostics, 1, len(p.tokens)-1)
+	if err != nil {
+		return err
+	}
+	st := texCoord{s: v[0]}
+	if len(v) > 1 {
+		st.t = v[1]
+	} // ignore 3rd parameter
+	p.model.texCoords = append(p.model.texCoords, st)
+	return nil
+}
 
-			if n0 < 0 {
-				n0 = len(ret.normals) + n0
-			}
-			if n1 < 0 {
-				n1 = len(ret.normals) + n1
-			}
-			if n2 < 0 {
-				n2 = len(ret.normals) + n2
-			}
-			if hasFour && n3 < 0 {
-				n3 = len(ret.normals) + n3
-			}
+// parseFace parses a face with any number of corners
+func (p *objParser) parseFace() error {
+	if ok, err := p.expectArgs(3, math.MaxInt32); !ok {
+		return err
+	}
+	// cases
+	//		1			v
+	//		1/2			v/t
+	//		1/2/3		v/t/n
+	//		1//3 		v//n
+	f := face{material: p.matIdx, smoothingGroup: p.smoothingGroup}
+	format := strings.Count(p.tokens[1].text, "/")
+	for i, tok := range p.tokens[1:] {
+		subParts := strings.Split(tok.text, "/")
+		if len(subParts)-1 != format || len(subParts) > 3 {
+			return p.report(p.errorAt(tok, "face corner %q does not match the format of the first corner", tok.text))
+		}
+		if i == 0 {
+			f.hasTexture = len(subParts) > 1 && len(subParts[1]) != 0
+			f.hasNormals = len(subParts) == 3
+		}
+		if (len(subParts) > 1 && len(subParts[1]) != 0) != f.hasTexture {
+			return p.report(p.errorAt(tok, "face corner %q does not match the format of the first corner", tok.text))
+		}
 
-			if t0 < 0 {
-				t0 = len(ret.texCoords) + t0
-			}
-			if t1 < 0 {
-				t1 = len(ret.texCoords) + t1
+		c := corner{v: -1, t: -1, n: -1, tg: -1}
+		var err *ParseError
+		if c.v, err = p.resolveIndex(tok, subParts[0], len(p.model.vertices), "vertex"); err != nil {
+			return p.report(err)
+		}
+		if f.hasTexture {
+			if c.t, err = p.resolveIndex(tok, subParts[1], len(p.model.texCoords), "texture coordinate"); err != nil {
+				return p.report(err)
 			}
-			if t2 < 0 {
-				t2 = len(ret.texCoords) + t2
+		}
+		if f.hasNormals {
+			if c.n, err = p.resolveIndex(tok, subParts[2], len(p.model.normals), "normal"); err != nil {
+				return p.report(err)
 			}
-			if hasFour && t3 < 0 {
-				t3 = len(ret.texCoords) + t3
+		}
+		f.corners = append(f.corners, c)
+	}
+	firstTriangle := len(p.model.triangles)
+	p.model.addFace(f)
+	p.currentGroup().addFace(firstTriangle)
+	return nil
+}
+
+// resolveIndex converts a 1-based index, which may be negative to reference the end of the list, to a 0-based index
+func (p *objParser) resolveIndex(tok token, s string, count int, kind string) (int, *ParseError) {
+	idx, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, p.errorAt(tok, "invalid %s index %q", kind, s)
+	}
+	if idx < 0 {
+		idx = count + idx
+	} else {
+		idx--
+	}
+	if idx < 0 || idx >= count {
+		return 0, p.errorAt(tok, "%s index %s out of range, %d defined so far", kind, s, count)
+	}
+	return idx, nil
+}
+
+func (p *objParser) parseMaterialLibs() error {
+	if ok, err := p.expectArgs(1, math.MaxInt32); !ok {
+		return err
+	}
+	for _, tok := range p.tokens[1:] {
+		file, err := p.resolver.Open(tok.text)
+		if err != nil {
+			if err := p.report(p.errorAt(tok, "material library: %v", err)); err != nil {
+				return err
 			}
+			continue
+		}
+		mats, err := parseMaterial(file, resolvedName(file, tok.text), tok.text, &p.diagnostics, p.resolver, p.bumpAsNormalMap)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		for _, mat := range mats {
+			mat.idx = len(p.model.materials)
+			p.model.materialMap[mat.name] = mat.idx
+			p.model.materials = append(p.model.materials, mat)
+		}
+	}
+	return nil
+}
+
+func (p *objParser) parseUseMaterial() error {
+	if ok, err := p.expectArgs(1, math.MaxInt32); !ok {
+		return err
+	}
+	name := p.rest(1)
+	idx, ok := p.model.materialMap[name]
+	if !ok {
+		p.matIdx = -1
+		return p.report(p.errorAt(p.tokens[1], "unknown material %q", name))
+	}
+	p.matIdx = idx
+	return nil
+}
+
+func (p *objParser) parseSmoothingGroup() error {
+	if ok, err := p.expectArgs(1, 1); !ok {
+		return err
+	}
+	p.model.hasSmoothingGroups = true
+	tok := p.tokens[1]
+	if tok.text == "off" {
+		p.smoothingGroup = 0
+		return nil
+	}
+	group, err := strconv.Atoi(tok.text)
+	if err != nil {
+		return p.report(p.errorAt(tok, "invalid smoothing group %q", tok.text))
+	}
+	p.smoothingGroup = group
+	return nil
+}
 
-			ret.triangles = append(ret.triangles, indices{
-				v0: v0 - 1, v1: v1 - 1, v2: v2 - 1, v3: v3 - 1,
-				n0: n0 - 1, n1: n1 - 1, n2: n2 - 1, n3: n3 - 1,
-				t0: t0 - 1, t1: t1 - 1, t2: t2 - 1, t3: t3 - 1,
-				material:   matIdx,
-				hasNormals: hasNormals,
-				hasTexture: hasTexture,
-				hasFour:    hasFour})
+// parseGroup handles the "o" and "g" directives, object names with spaces are kept as a single name
+func (p *objParser) parseGroup() error {
+	name := "default"
+	if p.tokens[0].text == "o" {
+		if len(p.tokens) > 1 {
+			name = p.rest(1)
+		}
+		p.object = name
+	} else if len(p.tokens) > 1 {
+		// faces belong to a single group, the faces of "g a b" are only added to the first one
+		name = p.tokens[1].text
+		if len(p.tokens) > 2 {
+			p.warn(p.errorAt(p.tokens[2], "faces can only belong to one group, they are added to %q", name))
 		}
 	}
+	p.groupName = name
+	p.group = nil
+	return nil
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+// currentGroup returns the group of the next face, creating it if necessary
+func (p *objParser) currentGroup() *Group {
+	if p.group != nil {
+		return p.group
 	}
+	key := [2]string{p.object, p.groupName}
+	p.group = p.groups[key]
+	if p.group == nil {
+		p.group = p.model.addGroup(p.object, p.groupName)
+		p.groups[key] = p.group
+	}
+	return p.group
+}
 
-	return ret, nil
+// addFace adds a face to the model and triangulates it
+func (o *Model) addFace(f face) {
+	o.faces = append(o.faces, f)
+	o.triangulate(len(o.faces) - 1)
 }
 
 // CenterVertices centers all the vertices in the model
@@ -230,81 +451,23 @@ func (m *Model) CenterVertices() {
 	for i := range m.vertices {
 		m.vertices[i] = math3d.Sub(m.vertices[i], v)
 	}
+	m.invalidateBounds()
 }
 
 // NormalizeVertices puts the model vertex data into a [-1, 1] interval
 func (m *Model) NormalizeVertices(scale float64) {
-	bbMin, bbMax := math3d.CalculateBoundingBox(m.vertices...)
-	max := math.Abs(bbMin.X)
-	max = math.Max(max, math.Abs(bbMax.X))
-	max = math.Max(max, math.Abs(bbMin.Y))
-	max = math.Max(max, math.Abs(bbMax.Y))
-	max = math.Max(max, math.Abs(bbMin.Z))
-	max = math.Max(max, math.Abs(bbMax.Z))
+	bb := math3d.NewAABB(m.vertices...)
+	max := math.Abs(bb.Min.X)
+	max = math.Max(max, math.Abs(bb.Max.X))
+	max = math.Max(max, math.Abs(bb.Min.Y))
+	max = math.Max(max, math.Abs(bb.Max.Y))
+	max = math.Max(max, math.Abs(bb.Min.Z))
+	max = math.Max(max, math.Abs(bb.Max.Z))
 	max = scale / max
 	for i := range m.vertices {
 		n := math3d.Mul(m.vertices[i], max)
 		n.W = 1
 		m.vertices[i] = n
 	}
-}
-
-func parseMaterial(filename string) ([]material, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	var ret []material
-	isNotEmpty := false
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		parts := strings.Split(scanner.Text(), " ")
-		if len(parts) == 2 {
-			if parts[0] == "newmtl" {
-				ret = append(ret, material{name: parts[1]})
-				isNotEmpty = true
-			} else if parts[0] == "map_Kd" && isNotEmpty {
-				texFilename := filepath.Join(filepath.Dir(filename), parts[1])
-				ret[len(ret)-1].mapKd = texFilename
-				img := rl.LoadImage(texFilename)
-				defer rl.UnloadImage(img)
-				rl.ImageFlipVertical(img)
-				ret[len(ret)-1].mapKdData = rl.GetImageData(img)
-				ret[len(ret)-1].mapKdWidth = int(img.Width)
-				ret[len(ret)-1].mapKdHeight = int(img.Height)
-			} else if parts[0] == "map_Ks" && isNotEmpty {
-				texFilename := filepath.Join(filepath.Dir(filename), parts[1])
-				ret[len(ret)-1].mapKs = texFilename
-				img := rl.LoadImage(texFilename)
-				defer rl.UnloadImage(img)
-				rl.ImageFlipVertical(img)
-				ret[len(ret)-1].mapKsData = rl.GetImageData(img)
-				ret[len(ret)-1].mapKsWidth = int(img.Width)
-				ret[len(ret)-1].mapKsHeight = int(img.Height)
-			} else if parts[0] == "Ns" && isNotEmpty {
-				v, _ := strconv.ParseFloat(parts[1], 32)
-				ret[len(ret)-1].specularExponent = v
-			}
-		} else if len(parts) == 4 && (parts[0] == "Ka" || parts[0] == "Kd" || parts[0] == "Ks") {
-			x, _ := strconv.ParseFloat(parts[1], 32)
-			y, _ := strconv.ParseFloat(parts[2], 32)
-			z, _ := strconv.ParseFloat(parts[3], 32)
-			col := m.Vector{X: x, Y: y, Z: z, W: 1}
-			if parts[0] == "Ka" {
-				ret[len(ret)-1].ambientColor = col
-			} else if parts[0] == "Kd" {
-				ret[len(ret)-1].diffuseColor = col
-			} else {
-				ret[len(ret)-1].specularColor = col
-			}
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
-	return ret, nil
+	m.invalidateBounds()
 }
@@ -0,0 +1,198 @@
+package obj
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	m "go-3d-rasterizer/math3d"
+)
+
+func TestParseVertexW(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		want     m.Vector
+		warnings int
+	}{
+		{"without w", "v 1 2 3", m.Vector{X: 1, Y: 2, Z: 3, W: 1}, 0},
+		{"with w", "v 1 2 3 0.5", m.Vector{X: 1, Y: 2, Z: 3, W: 0.5}, 0},
+		{"invalid w", "v 1 2 3 x", m.Vector{X: 1, Y: 2, Z: 3, W: 1}, 1},
+		{"invalid w with color", "v 1 2 3 x 1 0 0", m.Vector{X: 1, Y: 2, Z: 3, W: 1}, 1},
+		{"invalid coordinate", "v 1 x 3 2", m.Vector{X: 1, Y: 0, Z: 3, W: 2}, 1},
+	}
+	for _, tt := range tests {
+		model, err := Parse(strings.NewReader(tt.line), ParseOptions{})
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if len(model.vertices) != 1 || model.vertices[0] != tt.want {
+			t.Errorf("%s: vertices = %v, want [%v]", tt.name, model.vertices, tt.want)
+		}
+		if len(model.Warnings()) != tt.warnings {
+			t.Errorf("%s: warnings = %v, want %d", tt.name, model.Warnings(), tt.warnings)
+		}
+		if _, err := Parse(strings.NewReader(tt.line), ParseOptions{Strict: true}); (err != nil) != (tt.warnings > 0) {
+			t.Errorf("%s: strict mode error %v", tt.name, err)
+		}
+	}
+}
+
+func TestParseGroups(t *testing.T) {
+	const obj = `
+v 0 0 0
+v 1 0 0
+v 1 1 0
+o my object
+g a b
+f 1 2 3
+mg 1 0.5
+g
+f 1 2 3
+`
+	model, err := Parse(strings.NewReader(obj), ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, g := range model.groups {
+		names = append(names, g.Object+"/"+g.Name)
+	}
+	if want := []string{"my object/a", "my object/default"}; strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("groups = %q, want %q", names, want)
+	}
+	// the second group name and mg are reported
+	if len(model.Warnings()) != 2 {
+		t.Errorf("warnings = %v, want 2", model.Warnings())
+	}
+}
+
+// triangleOBJ defines the vertices of a triangle, it is followed by the lines of a test
+const triangleOBJ = "v 0 0 0\nv 1 0 0\nv 1 1 0\n"
+
+func TestParseLineSyntax(t *testing.T) {
+	tests := []struct {
+		name    string
+		obj     string
+		corners []int
+	}{
+		{"tabs", "v\t1\t2\t3\nv 4 5 6\nv 7 8 9\nf\t1\t2\t3\n", []int{0, 1, 2}},
+		{"repeated spaces", "v  1   2 3 \nv 4 5 6\nv 7 8 9\nf 1  2    3\n", []int{0, 1, 2}},
+		{"trailing comments", "v 1 2 3 # first\nv 4 5 6#second\nv 7 8 9\n# a comment line\nf 1 2 3 # a face\n", []int{0, 1, 2}},
+		{"continuation", "v 1 2 \\\n 3\nv 4 5 6\nv 7 8 9\nf 1 \\\n2 \\\n3\n", []int{0, 1, 2}},
+		{"continuation with comment", "v 1 2 3\nv 4 5 6\nv 7 8 9\nf 3 2 \\ # the last corner follows\n1\n", []int{2, 1, 0}},
+		{"windows line endings", "v 1 2 3\r\nv 4 5 6\r\nv 7 8 9\r\nf 1 2 3\r\n", []int{0, 1, 2}},
+	}
+	want := []m.Vector{{X: 1, Y: 2, Z: 3, W: 1}, {X: 4, Y: 5, Z: 6, W: 1}, {X: 7, Y: 8, Z: 9, W: 1}}
+	for _, tt := range tests {
+		model, err := Parse(strings.NewReader(tt.obj), ParseOptions{Strict: true})
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(model.vertices, want) {
+			t.Errorf("%s: vertices = %v, want %v", tt.name, model.vertices, want)
+		}
+		if len(model.faces) != 1 {
+			t.Errorf("%s: %d faces, want 1", tt.name, len(model.faces))
+			continue
+		}
+		var corners []int
+		for _, c := range model.faces[0].corners {
+			corners = append(corners, c.v)
+		}
+		if !reflect.DeepEqual(corners, tt.corners) {
+			t.Errorf("%s: corners = %v, want %v", tt.name, corners, tt.corners)
+		}
+	}
+}
+
+func TestParseUnknownMaterial(t *testing.T) {
+	obj := triangleOBJ + "usemtl missing\nf 1 2 3\n"
+	model, err := Parse(strings.NewReader(obj), ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.faces) != 1 || model.faces[0].material != -1 {
+		t.Errorf("faces = %v, want a face without material", model.faces)
+	}
+	if len(model.Warnings()) != 1 || !strings.Contains(model.Warnings()[0].Msg, `unknown material "missing"`) {
+		t.Errorf("warnings = %v, want the unknown material", model.Warnings())
+	}
+	if _, err := Parse(strings.NewReader(obj), ParseOptions{Strict: true}); err == nil {
+		t.Errorf("strict mode accepted the unknown material")
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	fsys := fstest.MapFS{
+		"models/model.obj": {Data: []byte(triangleOBJ + "# a comment\nf 1 2  \t7\n")},
+	}
+	_, err := ParseFS(fsys, "models/model.obj", ParseOptions{Strict: true})
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("error %v, want a *ParseError", err)
+	}
+	if perr.File != "models/model.obj" || perr.Line != 5 || perr.Column != 9 {
+		t.Errorf("error at %s:%d:%d, want models/model.obj:5:9", perr.File, perr.Line, perr.Column)
+	}
+	if want := "models/model.obj:5:9: vertex index 7 out of range, 3 defined so far"; err.Error() != want {
+		t.Errorf("error %q, want %q", err.Error(), want)
+	}
+
+	// models parsed from a reader have no file name
+	_, err = Parse(strings.NewReader("v 1 x 3\n"), ParseOptions{Strict: true})
+	if want := `1:5: invalid number "x"`; err == nil || err.Error() != want {
+		t.Errorf("error %v, want %q", err, want)
+	}
+}
+
+func TestParseFaceIndices(t *testing.T) {
+	tests := []struct {
+		name    string
+		face    string
+		corners []int // nil if the face is invalid
+	}{
+		{"positive", "f 1 2 3", []int{0, 1, 2}},
+		{"negative", "f -3 -2 -1", []int{0, 1, 2}},
+		{"zero", "f 0 1 2", nil},
+		{"out of range", "f 1 2 4", nil},
+		{"negative out of range", "f -4 1 2", nil},
+		{"not a number", "f 1 2 c", nil},
+		{"missing texture coordinate", "f 1/1 2/1 3/1", nil},
+		{"missing normal", "f 1//1 2//1 3//1", nil},
+		{"mixed formats", "f 1 2/1 3", nil},
+	}
+	for _, tt := range tests {
+		obj := triangleOBJ + tt.face + "\n"
+		model, err := Parse(strings.NewReader(obj), ParseOptions{})
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if tt.corners == nil {
+			// lenient mode reports the face and skips it
+			if len(model.faces) != 0 || len(model.Warnings()) != 1 {
+				t.Errorf("%s: %d faces, warnings = %v, want the face to be skipped with 1 warning", tt.name, len(model.faces), model.Warnings())
+			}
+		} else {
+			var corners []int
+			for _, f := range model.faces {
+				for _, c := range f.corners {
+					corners = append(corners, c.v)
+				}
+			}
+			if !reflect.DeepEqual(corners, tt.corners) || len(model.Warnings()) != 0 {
+				t.Errorf("%s: corners = %v, warnings = %v, want %v", tt.name, corners, model.Warnings(), tt.corners)
+			}
+		}
+
+		_, err = Parse(strings.NewReader(obj), ParseOptions{Strict: true})
+		if (err != nil) != (tt.corners == nil) {
+			t.Errorf("%s: strict mode error %v", tt.name, err)
+		}
+	}
+}
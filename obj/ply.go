@@ -0,0 +1,430 @@
+package obj
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	m "go-3d-rasterizer/math3d"
+	"io/ioutil"
+	"math"
+	"strconv"
+)
+
+// byte sizes of the scalar property types, the second name of each type is used by newer exporters
+var plyTypeSizes = map[string]int{
+	"char": 1, "int8": 1,
+	"uchar": 1, "uint8": 1,
+	"short": 2, "int16": 2,
+	"ushort": 2, "uint16": 2,
+	"int": 4, "int32": 4,
+	"uint": 4, "uint32": 4,
+	"float": 4, "float32": 4,
+	"double": 8, "float64": 8,
+}
+
+// plyProperty is a scalar or list property of an element
+type plyProperty struct {
+	name      string
+	typ       string
+	countType string // type of the list length, empty for scalar properties
+}
+
+// plyElement is an element declaration of the header, like "element vertex 8"
+type plyElement struct {
+	name  string
+	count int
+	props []plyProperty
+}
+
+// property returns the index of the first property with one of the names, or -1
+func (el *plyElement) property(names ...string) int {
+	for i, p := range el.props {
+		for _, name := range names {
+			if p.name == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// plyLoader holds the state of the ply importer
+type plyLoader struct {
+	diagnostics
+	file     string
+	model    *Model
+	elements []*plyElement
+
+	// ascii files are read with the tokenizer, binary files from data
+	t      *tokenizer
+	data   []byte
+	offset int
+	order  binary.ByteOrder // nil for ascii files
+}
+
+// ParsePLY loads an ascii, binary little endian or binary big endian ply file.
+// Vertex colors are kept and multiplied with the color of the faces, files without faces are rendered as point clouds
+// source: http://paulbourke.net/dataformats/ply/
+func ParsePLY(filename string) (*Model, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	l := &plyLoader{
+		file:  filename,
+		model: &Model{sampler: DefaultSampler, materialMap: make(map[string]int)},
+	}
+	body, lines, err := l.parseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	// every element takes at least a byte, larger counts can't be satisfied by the file and are not allocated
+	for _, el := range l.elements {
+		if el.count > len(body) {
+			return nil, &ParseError{File: filename, Msg: fmt.Sprintf("%d %s elements exceed the size of the file", el.count, el.name)}
+		}
+	}
+	if l.order == nil {
+		l.t = newTokenizer(bytes.NewReader(body), filename)
+		l.t.line = lines
+	} else {
+		l.data = body
+	}
+
+	var faces [][]float64
+	for _, el := range l.elements {
+		switch el.name {
+		case "vertex":
+			err = l.readVertices(el)
+		case "face":
+			faces, err = l.readFaces(el)
+		default:
+			err = l.skipElements(el)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	// faces may be declared before the vertices, their indices are checked once all elements are read
+	if err := l.addFaces(faces); err != nil {
+		return nil, err
+	}
+	l.model.warnings = l.warnings
+	return l.model, nil
+}
+
+// parseHeader reads the element declarations and returns the data following "end_header",
+// together with the number of lines of the header
+func (l *plyLoader) parseHeader(data []byte) ([]byte, int, error) {
+	if !bytes.HasPrefix(data, []byte("ply")) {
+		return nil, 0, &ParseError{File: l.file, Msg: "not a ply file, the magic number \"ply\" is missing"}
+	}
+	// the binary data starts after the line ending of end_header
+	end := bytes.Index(data, []byte("end_header"))
+	if end < 0 {
+		return nil, 0, &ParseError{File: l.file, Msg: "end_header is missing"}
+	}
+	bodyStart := bytes.IndexByte(data[end:], '\n')
+	if bodyStart < 0 {
+		bodyStart = len(data)
+	} else {
+		bodyStart += end + 1
+	}
+
+	t := newTokenizer(bytes.NewReader(data[:bodyStart]), l.file)
+	hasFormat := false
+	for t.next() {
+		keyword := t.tokens[0]
+		args := len(t.tokens) - 1
+		switch keyword.text {
+		case "ply", "end_header":
+		case "comment", "obj_info":
+		case "format":
+			if args != 2 {
+				return nil, 0, t.errorAt(keyword, "format expects 2 arguments, got %d", args)
+			}
+			switch t.tokens[1].text {
+			case "ascii":
+			case "binary_little_endian":
+				l.order = binary.LittleEndian
+			case "binary_big_endian":
+				l.order = binary.BigEndian
+			default:
+				return nil, 0, t.errorAt(t.tokens[1], "unknown format %q", t.tokens[1].text)
+			}
+			hasFormat = true
+		case "element":
+			if args != 2 {
+				return nil, 0, t.errorAt(keyword, "element expects 2 arguments, got %d", args)
+			}
+			count, err := strconv.Atoi(t.tokens[2].text)
+			if err != nil || count < 0 {
+				return nil, 0, t.errorAt(t.tokens[2], "invalid element count %q", t.tokens[2].text)
+			}
+			l.elements = append(l.elements, &plyElement{name: t.tokens[1].text, count: count})
+		case "property":
+			if len(l.elements) == 0 {
+				return nil, 0, t.errorAt(keyword, "property outside of an element")
+			}
+			var prop plyProperty
+			switch {
+			case args == 4 && t.tokens[1].text == "list":
+				prop = plyProperty{countType: t.tokens[2].text, typ: t.tokens[3].text, name: t.tokens[4].text}
+				if plyTypeSizes[prop.countType] == 0 {
+					return nil, 0, t.errorAt(t.tokens[2], "unknown property type %q", prop.countType)
+				}
+			case args == 2:
+				prop = plyProperty{typ: t.tokens[1].text, name: t.tokens[2].text}
+			default:
+				return nil, 0, t.errorAt(keyword, "expected \"property type name\" or \"property list count_type type name\"")
+			}
+			if plyTypeSizes[prop.typ] == 0 {
+				return nil, 0, t.errorAt(t.tokens[len(t.tokens)-2], "unknown property type %q", prop.typ)
+			}
+			el := l.elements[len(l.elements)-1]
+			el.props = append(el.props, prop)
+		default:
+			l.warn(t.errorAt(keyword, "unknown header keyword %q skipped", keyword.text))
+		}
+	}
+	if err := t.err(); err != nil {
+		return nil, 0, err
+	}
+	if !hasFormat {
+		return nil, 0, &ParseError{File: l.file, Msg: "format is missing"}
+	}
+	return data[bodyStart:], t.line, nil
+}
+
+// readElement reads the next instance of an element, values holds a slice per property which has a single entry
+// for scalar properties and all entries for list properties
+func (l *plyLoader) readElement(el *plyElement, values [][]float64) error {
+	if l.order != nil {
+		for i, p := range el.props {
+			values[i] = values[i][:0]
+			count := 1
+			if p.countType != "" {
+				v, err := l.binaryValue(p.countType)
+				if err != nil {
+					return err
+				}
+				count = int(v)
+			}
+			for j := 0; j < count; j++ {
+				v, err := l.binaryValue(p.typ)
+				if err != nil {
+					return err
+				}
+				values[i] = append(values[i], v)
+			}
+		}
+		return nil
+	}
+
+	// every element is written on its own line
+	if !l.t.next() {
+		if err := l.t.err(); err != nil {
+			return err
+		}
+		return &ParseError{File: l.file, Msg: fmt.Sprintf("unexpected end of file, expected %d %s elements", el.count, el.name)}
+	}
+	next := 0
+	value := func() (float64, error) {
+		if next >= len(l.t.tokens) {
+			return 0, l.t.errorAt(l.t.tokens[0], "%s element has too few values", el.name)
+		}
+		tok := l.t.tokens[next]
+		next++
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return 0, l.report(l.t.errorAt(tok, "invalid number %q", tok.text))
+		}
+		return v, nil
+	}
+	for i, p := range el.props {
+		values[i] = values[i][:0]
+		count := 1
+		if p.countType != "" {
+			v, err := value()
+			if err != nil {
+				return err
+			}
+			count = int(v)
+		}
+		for j := 0; j < count; j++ {
+			v, err := value()
+			if err != nil {
+				return err
+			}
+			values[i] = append(values[i], v)
+		}
+	}
+	if next < len(l.t.tokens) {
+		return l.report(l.t.errorAt(l.t.tokens[next], "%s element has too many values", el.name))
+	}
+	return nil
+}
+
+// binaryValue decodes the next value of the binary data
+func (l *plyLoader) binaryValue(typ string) (float64, error) {
+	size := plyTypeSizes[typ]
+	if l.offset+size > len(l.data) {
+		return 0, &ParseError{File: l.file, Msg: "binary data is truncated"}
+	}
+	b := l.data[l.offset : l.offset+size]
+	l.offset += size
+	switch typ {
+	case "char", "int8":
+		return float64(int8(b[0])), nil
+	case "uchar", "uint8":
+		return float64(b[0]), nil
+	case "short", "int16":
+		return float64(int16(l.order.Uint16(b))), nil
+	case "ushort", "uint16":
+		return float64(l.order.Uint16(b)), nil
+	case "int", "int32":
+		return float64(int32(l.order.Uint32(b))), nil
+	case "uint", "uint32":
+		return float64(l.order.Uint32(b)), nil
+	case "float", "float32":
+		return float64(math.Float32frombits(l.order.Uint32(b))), nil
+	}
+	return math.Float64frombits(l.order.Uint64(b)), nil
+}
+
+// colorScale maps the range of a color property type to [0, 1], integer colors use the full range of the type
+func colorScale(typ string) float64 {
+	switch typ {
+	case "uchar", "uint8":
+		return 1. / 255
+	case "ushort", "uint16":
+		return 1. / 65535
+	}
+	return 1
+}
+
+// readVertices reads the positions and the optional normals, texture coordinates and colors of the vertices
+func (l *plyLoader) readVertices(el *plyElement) error {
+	o := l.model
+	x, y, z := el.property("x"), el.property("y"), el.property("z")
+	if x < 0 || y < 0 || z < 0 {
+		return &ParseError{File: l.file, Msg: "vertex element needs the properties x, y and z"}
+	}
+	nx, ny, nz := el.property("nx"), el.property("ny"), el.property("nz")
+	hasNormals := nx >= 0 && ny >= 0 && nz >= 0
+	s, t := el.property("s", "u", "texture_u", "texture_s"), el.property("t", "v", "texture_v", "texture_t")
+	hasTexCoords := s >= 0 && t >= 0
+	r, g, b := el.property("red", "diffuse_red"), el.property("green", "diffuse_green"), el.property("blue", "diffuse_blue")
+	a := el.property("alpha", "diffuse_alpha")
+	hasColors := r >= 0 && g >= 0 && b >= 0
+
+	values := make([][]float64, len(el.props))
+	// list properties of vertices are not used, their value is ignored
+	get := func(i int) float64 {
+		if len(values[i]) == 0 {
+			return 0
+		}
+		return values[i][0]
+	}
+	for i := 0; i < el.count; i++ {
+		if err := l.readElement(el, values); err != nil {
+			return err
+		}
+		o.vertices = append(o.vertices, m.Vector{X: get(x), Y: get(y), Z: get(z), W: 1})
+		if hasNormals {
+			o.normals = append(o.normals, m.Vector{X: get(nx), Y: get(ny), Z: get(nz), W: 1})
+		}
+		if hasTexCoords {
+			o.texCoords = append(o.texCoords, texCoord{s: get(s), t: get(t)})
+		}
+		if hasColors {
+			col := m.Vector{
+				X: get(r) * colorScale(el.props[r].typ),
+				Y: get(g) * colorScale(el.props[g].typ),
+				Z: get(b) * colorScale(el.props[b].typ),
+				W: 1,
+			}
+			if a >= 0 {
+				col.W = get(a) * colorScale(el.props[a].typ)
+			}
+			o.colors = append(o.colors, col)
+		}
+	}
+	return nil
+}
+
+// readFaces reads the vertex indices of the faces
+func (l *plyLoader) readFaces(el *plyElement) ([][]float64, error) {
+	idx := el.property("vertex_indices", "vertex_index")
+	if idx < 0 || el.props[idx].countType == "" {
+		l.warn(&ParseError{File: l.file, Msg: "face element without a vertex_indices list skipped"})
+		return nil, l.skipElements(el)
+	}
+	values := make([][]float64, len(el.props))
+	var faces [][]float64
+	for i := 0; i < el.count; i++ {
+		if err := l.readElement(el, values); err != nil {
+			return nil, err
+		}
+		faces = append(faces, append([]float64(nil), values[idx]...))
+	}
+	return faces, nil
+}
+
+// skipElements reads the elements which are not used
+func (l *plyLoader) skipElements(el *plyElement) error {
+	values := make([][]float64, len(el.props))
+	for i := 0; i < el.count; i++ {
+		if err := l.readElement(el, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addFaces adds the faces to a single group, all of them use the gray default material.
+// The normals and texture coordinates are stored per vertex, so they share the index of the vertex
+func (l *plyLoader) addFaces(faces [][]float64) error {
+	if len(faces) == 0 {
+		return nil
+	}
+	o := l.model
+	mat := o.addDefaultMaterial()
+	g := o.addGroup("", "default")
+	hasNormals := len(o.normals) == len(o.vertices)
+	hasTexture := len(o.texCoords) == len(o.vertices)
+	for i, indices := range faces {
+		if len(indices) < 3 {
+			if err := l.report(&ParseError{File: l.file, Msg: fmt.Sprintf("face %d with %d vertices skipped", i, len(indices))}); err != nil {
+				return err
+			}
+			continue
+		}
+		f := face{material: mat, hasNormals: hasNormals, hasTexture: hasTexture}
+		valid := true
+		for _, v := range indices {
+			if v < 0 || int(v) >= len(o.vertices) {
+				valid = false
+				break
+			}
+			c := corner{v: int(v), t: -1, n: -1, tg: -1}
+			if hasNormals {
+				c.n = c.v
+			}
+			if hasTexture {
+				c.t = c.v
+			}
+			f.corners = append(f.corners, c)
+		}
+		if !valid {
+			if err := l.report(&ParseError{File: l.file, Msg: fmt.Sprintf("face %d has an invalid vertex index, skipped", i)}); err != nil {
+				return err
+			}
+			continue
+		}
+		firstTriangle := len(o.triangles)
+		o.addFace(f)
+		g.addFace(firstTriangle)
+	}
+	return nil
+}
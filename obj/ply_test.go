@@ -0,0 +1,176 @@
+package obj
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	m "go-3d-rasterizer/math3d"
+)
+
+// quadHeader declares the vertices and the face of a quad, the format is filled in by quadPLY
+const quadHeader = `ply
+format %s 1.0
+comment a quad with a color per vertex
+element vertex 4
+property float x
+property float y
+property float z
+property uchar red
+property uchar green
+property uchar blue
+element face 1
+property list uchar int vertex_indices
+end_header
+`
+
+// quadVertices holds x, y, z, red, green and blue of the quad
+var quadVertices = [4][6]float64{
+	{0, 0, 0, 255, 0, 0},
+	{1, 0, 0, 0, 255, 0},
+	{1, 1, 0, 0, 0, 255},
+	{0, 1, 0.5, 255, 255, 255},
+}
+
+// quadPLY encodes the quad as ascii, binary_little_endian or binary_big_endian file
+func quadPLY(format string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, quadHeader, format)
+	if format == "ascii" {
+		for _, v := range quadVertices {
+			fmt.Fprintf(&buf, "%g %g %g %g %g %g\n", v[0], v[1], v[2], v[3], v[4], v[5])
+		}
+		buf.WriteString("4 0 1 2 3\n")
+		return buf.Bytes()
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if format == "binary_big_endian" {
+		order = binary.BigEndian
+	}
+	for _, v := range quadVertices {
+		binary.Write(&buf, order, [3]float32{float32(v[0]), float32(v[1]), float32(v[2])})
+		buf.Write([]byte{uint8(v[3]), uint8(v[4]), uint8(v[5])})
+	}
+	buf.WriteByte(4)
+	binary.Write(&buf, order, [4]int32{0, 1, 2, 3})
+	return buf.Bytes()
+}
+
+// parsePLYData writes the data to a temporary file and parses it
+func parsePLYData(t *testing.T, data []byte) (*Model, error) {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "model.ply")
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return ParsePLY(filename)
+}
+
+func TestParsePLYFormats(t *testing.T) {
+	var wantVertices, wantColors []m.Vector
+	for _, v := range quadVertices {
+		wantVertices = append(wantVertices, m.Vector{X: v[0], Y: v[1], Z: v[2], W: 1})
+		wantColors = append(wantColors, m.Vector{X: v[3] / 255, Y: v[4] / 255, Z: v[5] / 255, W: 1})
+	}
+	for _, format := range []string{"ascii", "binary_little_endian", "binary_big_endian"} {
+		model, err := parsePLYData(t, quadPLY(format))
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", format, err)
+			continue
+		}
+		if len(model.Warnings()) > 0 {
+			t.Errorf("%s: unexpected warnings %v", format, model.Warnings())
+		}
+		if !reflect.DeepEqual(model.vertices, wantVertices) {
+			t.Errorf("%s: vertices = %v, want %v", format, model.vertices, wantVertices)
+		}
+		if !reflect.DeepEqual(model.colors, wantColors) {
+			t.Errorf("%s: colors = %v, want %v", format, model.colors, wantColors)
+		}
+		if len(model.faces) != 1 || len(model.faces[0].corners) != 4 || len(model.triangles) != 2 {
+			t.Errorf("%s: %d faces and %d triangles, want a quad with 2 triangles", format, len(model.faces), len(model.triangles))
+			continue
+		}
+		for i, c := range model.faces[0].corners {
+			if c.v != i {
+				t.Errorf("%s: corner %d references vertex %d", format, i, c.v)
+			}
+		}
+	}
+}
+
+func TestParsePLYPointCloud(t *testing.T) {
+	const ply = `ply
+format ascii 1.0
+element vertex 3
+property double x
+property double y
+property double z
+end_header
+0 0 0
+1 2 3
+-1 -2 -3
+`
+	model, err := parsePLYData(t, []byte(ply))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.vertices) != 3 || len(model.faces) != 0 || len(model.groups) != 0 {
+		t.Errorf("%d vertices, %d faces and %d groups, want 3 vertices without faces", len(model.vertices), len(model.faces), len(model.groups))
+	}
+	if len(model.colors) != 0 {
+		t.Errorf("colors = %v, want none", model.colors)
+	}
+}
+
+// colorNear compares colors with the precision of the color types
+func colorNear(a, b m.Vector) bool {
+	const epsilon = 1e-6
+	return math.Abs(a.X-b.X) <= epsilon && math.Abs(a.Y-b.Y) <= epsilon &&
+		math.Abs(a.Z-b.Z) <= epsilon && math.Abs(a.W-b.W) <= epsilon
+}
+
+func TestParsePLYColorScale(t *testing.T) {
+	tests := []struct {
+		name   string
+		typ    string
+		values string
+		want   m.Vector
+	}{
+		{"uchar", "uchar", "255 51 0 255", m.Vector{X: 1, Y: 0.2, Z: 0, W: 1}},
+		{"uint8", "uint8", "0 255 102 51", m.Vector{X: 0, Y: 1, Z: 0.4, W: 0.2}},
+		{"ushort", "ushort", "65535 0 13107 65535", m.Vector{X: 1, Y: 0, Z: 0.2, W: 1}},
+		{"float", "float", "0.5 0.25 1 0.75", m.Vector{X: 0.5, Y: 0.25, Z: 1, W: 0.75}},
+	}
+	for _, tt := range tests {
+		ply := "ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\nproperty float y\nproperty float z\n"
+		for _, c := range []string{"red", "green", "blue", "alpha"} {
+			ply += "property " + tt.typ + " " + c + "\n"
+		}
+		ply += "end_header\n0 0 0 " + tt.values + "\n"
+		model, err := parsePLYData(t, []byte(ply))
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if len(model.colors) != 1 || !colorNear(model.colors[0], tt.want) {
+			t.Errorf("%s: colors = %v, want [%v]", tt.name, model.colors, tt.want)
+		}
+	}
+}
+
+func TestParsePLYElementCount(t *testing.T) {
+	// the count must not be used to allocate memory before the data has been read
+	const ply = "ply\nformat ascii 1.0\nelement face 4000000000000\nproperty list uchar int vertex_indices\nend_header\n3 0 1 2\n"
+	_, err := parsePLYData(t, []byte(ply))
+	if err == nil || !strings.Contains(err.Error(), "exceed the size of the file") {
+		t.Errorf("error %v, want the element count to be rejected", err)
+	}
+}
@@ -4,169 +4,403 @@ import (
 	m "go-3d-rasterizer/math3d"
 	"go-3d-rasterizer/rasterizer"
 	"math"
-
-	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
-// RenderWireframe renders the model in wireframe mode
+// RenderWireframe renders the visible groups of the model in wireframe mode, drawing the edges of the original polygons.
+// Models without faces are rendered as point clouds
 func (o *Model) RenderWireframe(scene *rasterizer.Scene) {
-	for _, t := range o.triangles {
-		col1, col2, col3, col4 := m.Vector{X: 0, Y: 0, Z: 0, W: 1}, m.Vector{X: 0, Y: 0, Z: 0, W: 1}, m.Vector{X: 0, Y: 0, Z: 0, W: 1}, m.Vector{X: 0, Y: 0, Z: 0, W: 1}
-
-		if t.hasTexture && len(o.materials) > 0 {
-			st0, st1, st2 := o.texCoords[t.t0], o.texCoords[t.t1], o.texCoords[t.t2]
-			col1 = pixelFromMaterial(o.materials[t.material], st0)
-			col2 = pixelFromMaterial(o.materials[t.material], st1)
-			col3 = pixelFromMaterial(o.materials[t.material], st2)
-			if t.hasFour {
-				st3 := o.texCoords[t.t3]
-				col4 = pixelFromMaterial(o.materials[t.material], st3)
+	o.renderWireframe(scene, o.visibleGroups())
+}
+
+// RenderGroupWireframe renders a single group in wireframe mode, independent of its visibility
+func (o *Model) RenderGroupWireframe(scene *rasterizer.Scene, g *Group) {
+	o.renderWireframe(scene, []*Group{g})
+}
+
+func (o *Model) renderWireframe(scene *rasterizer.Scene, groups []*Group) {
+	if len(o.faces) == 0 {
+		o.RenderPoints(scene)
+		return
+	}
+	if scene.FrustumCull(o.BoundingBox()) {
+		return
+	}
+	for _, g := range o.frustumCull(scene, groups) {
+		for _, r := range g.ranges {
+			for _, f := range o.faces[r.firstFace:r.lastFace] {
+				colors := make([]m.Vector, len(f.corners))
+				for i, c := range f.corners {
+					colors[i] = m.Vector{X: 0, Y: 0, Z: 0, W: 1}
+					if f.hasTexture && f.material != -1 {
+						colors[i] = o.pixelFromMaterial(o.materials[f.material], o.texCoords[c.t])
+					} else if len(o.colors) > 0 {
+						colors[i] = o.colors[c.v]
+					}
+				}
+				for i, c := range f.corners {
+					j := (i + 1) % len(f.corners)
+					scene.RasterizeLine(o.vertices[c.v], o.vertices[f.corners[j].v], colors[i], colors[j])
+				}
 			}
 		}
-		if t.hasFour {
-			scene.DrawTriangleWireframe(o.vertices[t.v0], o.vertices[t.v1], o.vertices[t.v2], col1, col2, col3)
-			scene.DrawTriangleWireframe(o.vertices[t.v0], o.vertices[t.v2], o.vertices[t.v3], col1, col3, col4)
-		} else {
-			scene.DrawTriangleWireframe(o.vertices[t.v0], o.vertices[t.v1], o.vertices[t.v2], col1, col2, col3)
+	}
+}
+
+// RenderPoints renders every vertex of the model as a point, with its vertex color or white
+func (o *Model) RenderPoints(scene *rasterizer.Scene) {
+	if scene.FrustumCull(o.BoundingBox()) {
+		return
+	}
+	white := m.Vector{X: 1, Y: 1, Z: 1, W: 1}
+	for i, v := range o.vertices {
+		color := white
+		if len(o.colors) > 0 {
+			color = o.colors[i]
 		}
+		scene.RasterizePoint(v, color, o.pointSize)
 	}
 }
 
-// RenderNormals renders the normals ontop of each vertex
+// SetPointSize sets the size in pixels of the points of point clouds, the default is 1
+func (o *Model) SetPointSize(size int) {
+	o.pointSize = size
+}
+
+// RenderNormals renders the normals ontop of each vertex of the visible groups
 func (o *Model) RenderNormals(scene *rasterizer.Scene) {
 	white := m.Vector{X: 1, Y: 1, Z: 1, W: 1}
-	for _, t := range o.triangles {
-		if !t.hasNormals {
-			continue
+	for _, g := range o.visibleGroups() {
+		for _, r := range g.ranges {
+			for _, f := range o.faces[r.firstFace:r.lastFace] {
+				if !f.hasNormals {
+					continue
+				}
+				for _, c := range f.corners {
+					scene.RasterizeLine(o.vertices[c.v], m.Add(o.vertices[c.v], o.normals[c.n]), white, white)
+				}
+			}
 		}
+	}
+}
+
+// offsets of the varyings used by the model shader
+const (
+	varyingColor    = 0 // r, g, b, a
+	varyingPosition = 4 // x, y, z
+	varyingNormal   = 7 // x, y, z
+	varyingTexCoord = 10
+	varyingTangent  = 12 // x, y, z, bitangent sign
+	varyingCount    = 16
+)
 
-		scene.RasterizeLine(o.vertices[t.v0], m.Add(o.vertices[t.v0], o.normals[t.n0]), white, white)
-		scene.RasterizeLine(o.vertices[t.v1], m.Add(o.vertices[t.v1], o.normals[t.n1]), white, white)
-		scene.RasterizeLine(o.vertices[t.v2], m.Add(o.vertices[t.v2], o.normals[t.n2]), white, white)
-		if !t.hasFour {
-			continue
+// varyingBlockSize is the number of vertices whose varyings are allocated at once
+const varyingBlockSize = 256
+
+// modelShader renders all triangles of a model which share the same material
+type modelShader struct {
+	model    *Model
+	mat      *material
+	mvp      m.Matrix
+	lit      bool
+	textured bool
+	tangents bool
+	lightDir m.Vector  // points towards the light source
+	camera   m.Vector  // position of the camera in model space
+	varyings []float64 // unused part of the current block of varyings
+}
+
+// shaderKey identifies the shader variant used for a triangle
+type shaderKey struct {
+	material int
+	lit      bool
+	textured bool
+	tangents bool
+}
+
+// allocVaryings returns the zeroed varyings of a vertex, which are taken from a larger block to avoid an allocation per vertex.
+// The slices are never reused, because the rasterizer keeps them until the binned triangles have been drawn
+func (ms *modelShader) allocVaryings() []float64 {
+	if len(ms.varyings) < varyingCount {
+		ms.varyings = make([]float64, varyingBlockSize*varyingCount)
+	}
+	ret := ms.varyings[:varyingCount:varyingCount]
+	ms.varyings = ms.varyings[varyingCount:]
+	return ret
+}
+
+// Vertex expects the index to be: triangle index * 3 + corner, with corner € [0, 2]
+func (ms *modelShader) Vertex(idx int) (m.Vector, []float64) {
+	t := ms.model.triangles[idx/3]
+	c := ms.model.faces[t.face].corners[t.corners[idx%3]]
+	pos := ms.model.vertices[c.v]
+
+	varyings := ms.allocVaryings()
+	if len(ms.model.colors) > 0 {
+		copy(varyings[varyingColor:], ms.model.colors[c.v].ToArray())
+	} else {
+		copy(varyings[varyingColor:], []float64{1, 1, 1, 1})
+	}
+	copy(varyings[varyingPosition:], pos.ToArray()[:3])
+	if ms.lit {
+		copy(varyings[varyingNormal:], ms.model.normals[c.n].ToArray()[:3])
+	}
+	if ms.textured {
+		varyings[varyingTexCoord] = ms.model.texCoords[c.t].s
+		varyings[varyingTexCoord+1] = ms.model.texCoords[c.t].t
+	}
+	if ms.tangents {
+		copy(varyings[varyingTangent:], ms.model.tangents[c.tg].ToArray())
+	}
+	return m.Transform(ms.mvp, pos, false), varyings
+}
+
+// Fragment samples the textures of the material and applies the blinn-phong lighting model
+func (ms *modelShader) Fragment(f *rasterizer.Fragment) (m.Vector, bool) {
+	color := varyingVector(f.Varyings, varyingColor)
+	color.W = f.Varyings[varyingColor+3]
+
+	mat := ms.mat
+	var tl texLookup
+	if ms.textured {
+		tl.s, tl.t = f.Varyings[varyingTexCoord], f.Varyings[varyingTexCoord+1]
+		tl.dsdx, tl.dsdy = f.Derivatives(varyingTexCoord)
+		tl.dtdx, tl.dtdy = f.Derivatives(varyingTexCoord + 1)
+		if mat.mapKd != nil {
+			color = m.MulComponentWise(color, mat.mapKd.sample(ms.model.sampler, tl))
 		}
-		scene.RasterizeLine(o.vertices[t.v3], m.Add(o.vertices[t.v3], o.normals[t.n3]), white, white)
-	}
-}
-
-func lightingCalculator(a, b, c,
-	normalA, normalB, normalC m.Vector,
-	st0, st1, st2 texCoord,
-	lightDir m.Vector, mat *material, s *rasterizer.Scene) rasterizer.LightingCalcCb {
-
-	lightDir = m.Mul(lightDir, -1)
-	return func(w, u, t float64, frameBufferIdx int) {
-		color := &s.Buffers.FrameBuffer[frameBufferIdx]
-		normalAvg := m.Normalize(m.Add(m.Add(m.Mul(normalA, w), m.Mul(normalB, u)), m.Mul(normalC, t)))
-		posAvg := m.Add(m.Add(m.Mul(a, w), m.Mul(b, u)), m.Mul(c, t))
-		dot := m.Dot(normalAvg, lightDir)
-		if dot >= 0 {
-			if mat != nil {
-				// ambient
-				lightCol := mat.ambientColor
-				// diffuse
-				lightCol = m.Add(lightCol, m.Mul(mat.diffuseColor, dot))
-				// specular
-				eye := m.Normalize(m.Mul(posAvg, -1))
-				half := m.Normalize(m.Sub(eye, lightDir))
-				halfNormal := math.Max(0, m.Dot(half, eye))
-				shininess := math.Pow(halfNormal, mat.specularExponent)
-				specularColor := mat.specularColor
-				if st0.s != -1 && mat.mapKsWidth > 0 {
-					x, y := stToXy(st0, mat.mapKsWidth, mat.mapKsHeight)
-					specCol1 := mat.mapKsData[mat.mapKsWidth*y+x]
-					x, y = stToXy(st1, mat.mapKsWidth, mat.mapKsHeight)
-					specCol2 := mat.mapKsData[mat.mapKsWidth*y+x]
-					x, y = stToXy(st2, mat.mapKsWidth, mat.mapKsHeight)
-					specCol3 := mat.mapKsData[mat.mapKsWidth*y+x]
-					specularColor = m.Add(m.Add(m.Mul(colorToVector(specCol1), w), m.Mul(colorToVector(specCol2), u)), m.Mul(colorToVector(specCol3), t))
-				}
-				lightCol = m.Add(lightCol, m.Mul(specularColor, shininess))
-				// final mixture:
-				// fragment color = fragment color * (ambient + diffuse + specular), clamped
-				*color = m.MulComponentWise(*color, m.ClampValue(lightCol, 0, 1))
-			}
-		} else {
-			*color = m.VectorOf(0)
+	}
+	if mat != nil {
+		color.W *= mat.dissolve
+		if ms.textured && mat.mapD != nil {
+			color.W *= mat.mapD.scalar(ms.model.sampler, tl)
+		}
+		if mat.alphaCutoff > 0 && color.W < mat.alphaCutoff || color.W <= 0 {
+			return color, false
+		}
+		if !mat.isTransparent() {
 			color.W = 1
 		}
 	}
+	if !ms.lit {
+		return color, true
+	}
+
+	normal := m.Normalize(varyingVector(f.Varyings, varyingNormal))
+	if ms.textured && (mat.bump != nil || mat.normalMap != nil) {
+		normal = ms.perturbNormal(f, normal, tl)
+	}
+	dot := m.Dot(normal, ms.lightDir)
+	if mat == nil {
+		if dot < 0 {
+			return m.Vector{X: 0, Y: 0, Z: 0, W: 1}, true
+		}
+		return color, true
+	}
+	emissiveColor := mat.emissiveColor
+	if ms.textured && mat.mapKe != nil {
+		emissiveColor = m.MulComponentWise(emissiveColor, mat.mapKe.sample(ms.model.sampler, tl))
+	}
+	if mat.illum == illumColor {
+		return withAlpha(m.Add(m.MulComponentWise(color, mat.diffuseColor), emissiveColor), color.W), true
+	}
+	if dot < 0 {
+		return withAlpha(emissiveColor, color.W), true
+	}
+
+	diffuseColor, specularColor, specularExponent := mat.diffuseColor, mat.specularColor, mat.specularExponent
+	if ms.textured && mat.mapKs != nil {
+		specularColor = mat.mapKs.sample(ms.model.sampler, tl)
+	}
+	if mat.pbr {
+		diffuseColor, specularColor, specularExponent = mat.metallicRoughness(ms.model.sampler, tl, ms.textured)
+	}
+
+	// ambient
+	lightCol := mat.ambientColor
+	// diffuse
+	lightCol = m.Add(lightCol, m.Mul(diffuseColor, dot))
+	// specular
+	eye := m.Normalize(m.Mul(varyingVector(f.Varyings, varyingPosition), -1))
+	if mat.illum != illumAmbient {
+		half := m.Normalize(m.Sub(eye, ms.lightDir))
+		halfNormal := math.Max(0, m.Dot(half, eye))
+		shininess := math.Pow(halfNormal, specularExponent)
+		lightCol = m.Add(lightCol, m.Mul(specularColor, shininess))
+	}
+	// glass gets more opaque at grazing angles, approximated with schlick's formula
+	// source: https://en.wikipedia.org/wiki/Schlick%27s_approximation
+	if mat.isGlass() {
+		view := m.Normalize(m.Sub(ms.camera, varyingVector(f.Varyings, varyingPosition)))
+		r0 := math.Pow((mat.opticalDensity-1)/(mat.opticalDensity+1), 2)
+		fresnel := r0 + (1-r0)*math.Pow(1-math.Abs(m.Dot(normal, view)), 5)
+		color.W += (1 - color.W) * fresnel
+	}
+	// final mixture:
+	// fragment color = fragment color * (ambient + diffuse + specular) + emission, clamped
+	lit := m.Add(m.MulComponentWise(color, m.ClampValue(lightCol, 0, 1)), emissiveColor)
+	return withAlpha(m.ClampValue(lit, 0, 1), color.W), true
 }
 
-// Render renders the obj model
-func (o *Model) Render(scene *rasterizer.Scene, useLighting bool, lightDirection m.Vector) {
-	for _, t := range o.triangles {
-		col1 := m.Vector{X: 1, Y: 1, Z: 1, W: 1}
-		col2, col3, col4 := col1, col1, col1
-
-		st0 := texCoord{s: -1, t: -1}
-		st1, st2, st3 := st0, st0, st0
-
-		if t.hasTexture && len(o.materials) > 0 {
-			st0, st1, st2 = o.texCoords[t.t0], o.texCoords[t.t1], o.texCoords[t.t2]
-			col1 = pixelFromMaterial(o.materials[t.material], st0)
-			col2 = pixelFromMaterial(o.materials[t.material], st1)
-			col3 = pixelFromMaterial(o.materials[t.material], st2)
-			if t.hasFour {
-				st3 = o.texCoords[t.t3]
-				col4 = pixelFromMaterial(o.materials[t.material], st3)
+// Blend enables alpha blending for transparent materials
+func (ms *modelShader) Blend() bool {
+	return ms.mat != nil && ms.mat.isTransparent()
+}
+
+// perturbNormal applies the bump map or normal map of the material to the interpolated normal.
+// Normal maps use the vertex tangents if the model has them, otherwise the tangent frame is calculated
+// from the screen space derivatives of the position and texture coordinate, just like for bump maps
+// source: https://mmikk.github.io/papers3d/mm_sfgrad_bump.pdf
+func (ms *modelShader) perturbNormal(f *rasterizer.Fragment, normal m.Vector, tl texLookup) m.Vector {
+	var dpdx, dpdy m.Vector
+	dpdx.X, dpdy.X = f.Derivatives(varyingPosition)
+	dpdx.Y, dpdy.Y = f.Derivatives(varyingPosition + 1)
+	dpdx.Z, dpdy.Z = f.Derivatives(varyingPosition + 2)
+	mat := ms.mat
+
+	if mat.normalMap != nil {
+		var tangent, bitangent m.Vector
+		if ms.tangents {
+			// like the MikkTSpace reference implementation, the interpolated tangent is not normalized
+			tangent = varyingVector(f.Varyings, varyingTangent)
+			bitangent = m.Mul(m.Cross(normal, tangent), f.Varyings[varyingTangent+3])
+		} else {
+			// without vertex tangents: solve dp = dP/ds * ds + dP/dt * dt for the tangent dP/ds and bitangent dP/dt
+			det := tl.dsdx*tl.dtdy - tl.dtdx*tl.dsdy
+			if det == 0 {
+				return normal
 			}
+			tangent = m.Mul(m.Sub(m.Mul(dpdx, tl.dtdy), m.Mul(dpdy, tl.dtdx)), 1/det)
+			bitangent = m.Mul(m.Sub(m.Mul(dpdy, tl.dsdx), m.Mul(dpdx, tl.dsdy)), 1/det)
+			tangent = orthogonalize(tangent, normal)
+			bitangent = orthogonalize(bitangent, normal)
 		}
-
-		var mat *material = nil
-		if t.material != -1 {
-			mat = &o.materials[t.material]
+		c := mat.normalMap.sample(ms.model.sampler, tl)
+		x, y, z := (c.X*2-1)*mat.normalMap.bumpMultiplier, (c.Y*2-1)*mat.normalMap.bumpMultiplier, c.Z*2-1
+		n := m.Add(m.Add(m.Mul(tangent, x), m.Mul(bitangent, y)), m.Mul(normal, z))
+		if m.Magnitude(n) == 0 {
+			return normal
 		}
+		return m.Normalize(n)
+	}
+
+	// height differences towards the neighbouring pixels
+	height := mat.bump.scalar(ms.model.sampler, tl)
+	tlx, tly := tl, tl
+	tlx.s, tlx.t = tl.s+tl.dsdx, tl.t+tl.dtdx
+	tly.s, tly.t = tl.s+tl.dsdy, tl.t+tl.dtdy
+	dBs := (mat.bump.scalar(ms.model.sampler, tlx) - height) * mat.bump.bumpMultiplier
+	dBt := (mat.bump.scalar(ms.model.sampler, tly) - height) * mat.bump.bumpMultiplier
+
+	r1 := m.Cross(dpdy, normal)
+	r2 := m.Cross(normal, dpdx)
+	det := m.Dot(dpdx, r1)
+	if det == 0 {
+		return normal
+	}
+	grad := m.Mul(m.Add(m.Mul(r1, dBs), m.Mul(r2, dBt)), math.Copysign(1, det))
+	n := m.Sub(m.Mul(normal, math.Abs(det)), grad)
+	if m.Magnitude(n) == 0 {
+		return normal
+	}
+	return m.Normalize(n)
+}
 
-		if t.hasFour {
-			if t.hasNormals {
-				var lightingCalc1 rasterizer.LightingCalcCb = nil
-				var lightingCalc2 rasterizer.LightingCalcCb = nil
-				if useLighting {
-					lightingCalc1 = lightingCalculator(o.vertices[t.v0], o.vertices[t.v1], o.vertices[t.v2],
-						o.normals[t.n0], o.normals[t.n1], o.normals[t.n2],
-						st0, st1, st2,
-						lightDirection, mat, scene)
-					lightingCalc2 = lightingCalculator(o.vertices[t.v0], o.vertices[t.v2], o.vertices[t.v3],
-						o.normals[t.n0], o.normals[t.n2], o.normals[t.n3],
-						st0, st2, st3,
-						lightDirection, mat, scene)
+// cameraPosition transforms the origin of the view space back into model space
+func cameraPosition(mv m.Matrix) m.Vector {
+	inv, ok := m.Inverse(mv)
+	if !ok {
+		return m.Vector{X: 0, Y: 0, Z: 0, W: 1}
+	}
+	return m.Transform(inv, m.Vector{X: 0, Y: 0, Z: 0, W: 1}, false)
+}
+
+// orthogonalize removes the part of v which is parallel to the normal and normalizes it
+func orthogonalize(v, normal m.Vector) m.Vector {
+	v = m.Sub(v, m.Mul(normal, m.Dot(normal, v)))
+	if m.Magnitude(v) == 0 {
+		return v
+	}
+	return m.Normalize(v)
+}
+
+// withAlpha returns the color with the given alpha channel
+func withAlpha(color m.Vector, alpha float64) m.Vector {
+	color.W = alpha
+	return color
+}
+
+// varyingVector reads 3 varyings starting at offset into a vector
+func varyingVector(varyings []float64, offset int) m.Vector {
+	return m.Vector{X: varyings[offset], Y: varyings[offset+1], Z: varyings[offset+2], W: 1}
+}
+
+// Render renders the visible groups of the obj model, models without faces are rendered as point clouds
+func (o *Model) Render(scene *rasterizer.Scene, useLighting bool, lightDirection m.Vector) {
+	o.render(scene, o.visibleGroups(), useLighting, lightDirection)
+}
+
+// RenderGroup renders a single group of the obj model, independent of its visibility
+func (o *Model) RenderGroup(scene *rasterizer.Scene, g *Group, useLighting bool, lightDirection m.Vector) {
+	o.render(scene, []*Group{g}, useLighting, lightDirection)
+}
+
+func (o *Model) render(scene *rasterizer.Scene, groups []*Group, useLighting bool, lightDirection m.Vector) {
+	if len(o.faces) == 0 {
+		o.RenderPoints(scene)
+		return
+	}
+	// models and groups outside of the view frustum are skipped before their triangles are transformed
+	if scene.FrustumCull(o.BoundingBox()) {
+		return
+	}
+	groups = o.frustumCull(scene, groups)
+	mvp := scene.ModelViewProjectionMatrix()
+	camera := cameraPosition(scene.ModelViewMatrix)
+	shaders := make(map[shaderKey]*modelShader)
+
+	// transparent materials are blended without updating the depth buffer, they are drawn after the opaque ones
+	for _, transparent := range []bool{false, true} {
+		for _, g := range groups {
+			for _, r := range g.ranges {
+				if o.isTransparent(r.material) != transparent {
+					continue
 				}
-				scene.RasterizeTriangle(o.vertices[t.v0], o.vertices[t.v1], o.vertices[t.v2], col1, col2, col3, lightingCalc1)
-				scene.RasterizeTriangle(o.vertices[t.v0], o.vertices[t.v2], o.vertices[t.v3], col1, col3, col4, lightingCalc2)
-			} else {
-				scene.DrawQuad(o.vertices[t.v0], o.vertices[t.v1], o.vertices[t.v2], o.vertices[t.v3], col1, col2, col3, col4)
-			}
-		} else {
-			if t.hasNormals {
-				var lightingCalc rasterizer.LightingCalcCb = nil
-				if useLighting {
-					lightingCalc = lightingCalculator(o.vertices[t.v0], o.vertices[t.v1], o.vertices[t.v2],
-						o.normals[t.n0], o.normals[t.n1], o.normals[t.n2],
-						st0, st1, st2,
-						lightDirection, mat, scene)
+				for i := r.firstTriangle; i < r.lastTriangle; i++ {
+					f := &o.faces[o.triangles[i].face]
+					key := shaderKey{
+						material: f.material,
+						lit:      useLighting && f.hasNormals,
+						textured: f.hasTexture && f.material != -1,
+					}
+					key.tangents = key.lit && key.textured && f.hasTangents && o.materials[f.material].normalMap != nil
+					shader := shaders[key]
+					if shader == nil {
+						shader = &modelShader{model: o, mvp: mvp, lit: key.lit, textured: key.textured, tangents: key.tangents, lightDir: m.Mul(lightDirection, -1), camera: camera}
+						if f.material != -1 {
+							shader.mat = &o.materials[f.material]
+						}
+						shaders[key] = shader
+					}
+					scene.DrawTriangle(shader, i*3, i*3+1, i*3+2)
 				}
-				scene.RasterizeTriangle(o.vertices[t.v0], o.vertices[t.v1], o.vertices[t.v2], col1, col2, col3, lightingCalc)
-			} else {
-				scene.RasterizeTriangle(o.vertices[t.v0], o.vertices[t.v1], o.vertices[t.v2], col1, col2, col3, nil)
 			}
 		}
 	}
 }
 
-func pixelFromMaterial(mat material, st texCoord) m.Vector {
-	if mat.mapKdWidth == 0 {
-		return m.Vector{X: 1, Y: 1, Z: 1, W: 1}
-	}
-	x, y := stToXy(st, mat.mapKdWidth, mat.mapKdHeight)
-	return colorToVector(mat.mapKdData[mat.mapKdWidth*y+x])
+// isTransparent reports whether the material with the given index is blended, -1 means no material
+func (o *Model) isTransparent(material int) bool {
+	return material != -1 && o.materials[material].isTransparent()
 }
 
-func stToXy(st texCoord, width, height int) (int, int) {
-	return int(st.s * float64(width-1)), int(st.t * float64(height-1))
+func (o *Model) pixelFromMaterial(mat material, st texCoord) m.Vector {
+	if mat.mapKd == nil {
+		return m.Vector{X: 1, Y: 1, Z: 1, W: 1}
+	}
+	return mat.mapKd.sample(o.sampler, texLookup{s: st.s, t: st.t})
 }
 
-func colorToVector(c rl.Color) m.Vector {
-	return m.Vector{X: float64(c.R) / 255., Y: float64(c.G) / 255., Z: float64(c.B) / 255., W: float64(c.A) / 255}
+// SetSampler configures how the textures of the model get filtered and wrapped
+func (o *Model) SetSampler(sampler Sampler) {
+	o.sampler = sampler
 }
@@ -0,0 +1,67 @@
+package obj
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Resolver opens the files which are referenced by a model, like material libraries and textures.
+// Names are slash separated paths relative to the directory of the model
+type Resolver interface {
+	Open(name string) (io.ReadCloser, error)
+}
+
+// DirResolver returns a resolver which opens the files relative to a directory of the file system
+func DirResolver(dir string) Resolver {
+	return dirResolver(dir)
+}
+
+type dirResolver string
+
+func (d dirResolver) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(string(d), filepath.FromSlash(name)))
+}
+
+// FSResolver returns a resolver which opens the files relative to a directory of fsys, like an embed.FS or a zip archive
+func FSResolver(fsys fs.FS, dir string) Resolver {
+	return &fsResolver{fsys: fsys, dir: dir}
+}
+
+type fsResolver struct {
+	fsys fs.FS
+	dir  string
+}
+
+func (r *fsResolver) Open(name string) (io.ReadCloser, error) {
+	return r.fsys.Open(path.Join(r.dir, name))
+}
+
+// noResolver is used if the options of Parse have no resolver, every referenced file is missing
+type noResolver struct{}
+
+func (noResolver) Open(name string) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("open %s: no resolver for referenced files", name)
+}
+
+// resolvedName returns the path of a resolved file if it has been opened from the file system, otherwise its name.
+// It is used for messages and as the filename of textures
+func resolvedName(rc io.ReadCloser, name string) string {
+	if file, ok := rc.(*os.File); ok {
+		return file.Name()
+	}
+	return name
+}
+
+// resolveTexture loads a png or jpeg texture with a resolver
+func resolveTexture(res Resolver, name string) (*Texture, error) {
+	rc, err := res.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return decodeTexture(rc, resolvedName(rc, name))
+}
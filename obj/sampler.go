@@ -0,0 +1,136 @@
+package obj
+
+import (
+	m "go-3d-rasterizer/math3d"
+	"math"
+)
+
+// Filter selects how texels are combined when a texture gets sampled
+type Filter int
+
+// WrapMode selects how texture coordinates outside of [0, 1] are handled
+type WrapMode int
+
+// texture filters, nearest and bilinear use the closest mip level, trilinear blends the two closest mip levels
+const (
+	FilterNearest Filter = iota
+	FilterBilinear
+	FilterTrilinear
+)
+
+// wrap modes
+const (
+	WrapRepeat WrapMode = iota
+	WrapClamp
+	WrapMirror
+)
+
+// Sampler reads filtered colors from a texture
+type Sampler struct {
+	Filter Filter
+	Wrap   WrapMode
+}
+
+// DefaultSampler is used by models, unless they are configured otherwise
+var DefaultSampler = Sampler{Filter: FilterTrilinear, Wrap: WrapRepeat}
+
+// Sample reads the color at the texture coordinate s, t from the base mip level
+func (sm Sampler) Sample(tex *Texture, s, t float64) m.Vector {
+	return sm.SampleLevel(tex, s, t, 0)
+}
+
+// SampleGrad reads the color at the texture coordinate s, t and selects the mip level
+// from the screen space derivatives of the texture coordinates
+// source: https://www.khronos.org/registry/OpenGL/specs/gl/glspec46.core.pdf (chapter 8.14)
+func (sm Sampler) SampleGrad(tex *Texture, s, t, dsdx, dtdx, dsdy, dtdy float64) m.Vector {
+	w, h := float64(tex.Width()), float64(tex.Height())
+	rhoX := math.Hypot(dsdx*w, dtdx*h)
+	rhoY := math.Hypot(dsdy*w, dtdy*h)
+	return sm.SampleLevel(tex, s, t, math.Log2(math.Max(rhoX, rhoY)))
+}
+
+// SampleLevel reads the color at the texture coordinate s, t from the given (fractional) mip level
+func (sm Sampler) SampleLevel(tex *Texture, s, t, lod float64) m.Vector {
+	maxLevel := float64(len(tex.levels) - 1)
+	if math.IsNaN(lod) || lod < 0 {
+		lod = 0
+	}
+	lod = math.Min(lod, maxLevel)
+
+	switch sm.Filter {
+	case FilterNearest:
+		return sm.nearest(tex, int(math.Round(lod)), s, t)
+	case FilterBilinear:
+		return sm.bilinear(tex, int(math.Round(lod)), s, t)
+	}
+	level := math.Floor(lod)
+	c0 := sm.bilinear(tex, int(level), s, t)
+	if level == lod {
+		return c0
+	}
+	c1 := sm.bilinear(tex, int(level)+1, s, t)
+	return lerpColor(c0, c1, lod-level)
+}
+
+func (sm Sampler) nearest(tex *Texture, level int, s, t float64) m.Vector {
+	w, h := tex.levels[level].Rect.Dx(), tex.levels[level].Rect.Dy()
+	x := sm.wrap(int(math.Floor(s*float64(w))), w)
+	y := sm.wrap(int(math.Floor(t*float64(h))), h)
+	return tex.texel(level, x, y)
+}
+
+func (sm Sampler) bilinear(tex *Texture, level int, s, t float64) m.Vector {
+	w, h := tex.levels[level].Rect.Dx(), tex.levels[level].Rect.Dy()
+	// texel centers are located at .5
+	fx := s*float64(w) - 0.5
+	fy := t*float64(h) - 0.5
+	x0, y0 := math.Floor(fx), math.Floor(fy)
+	ax, ay := fx-x0, fy-y0
+
+	x1 := sm.wrap(int(x0)+1, w)
+	y1 := sm.wrap(int(y0)+1, h)
+	xi := sm.wrap(int(x0), w)
+	yi := sm.wrap(int(y0), h)
+
+	row0 := lerpColor(tex.texel(level, xi, yi), tex.texel(level, x1, yi), ax)
+	row1 := lerpColor(tex.texel(level, xi, y1), tex.texel(level, x1, y1), ax)
+	return lerpColor(row0, row1, ay)
+}
+
+// wrap maps a texel coordinate into [0, size)
+func (sm Sampler) wrap(i, size int) int {
+	switch sm.Wrap {
+	case WrapClamp:
+		if i < 0 {
+			return 0
+		}
+		if i >= size {
+			return size - 1
+		}
+		return i
+	case WrapMirror:
+		i %= 2 * size
+		if i < 0 {
+			i += 2 * size
+		}
+		if i >= size {
+			i = 2*size - 1 - i
+		}
+		return i
+	}
+	i %= size
+	if i < 0 {
+		i += size
+	}
+	return i
+}
+
+// lerpColor linearly interpolates all four color channels
+func lerpColor(a, b m.Vector, t float64) m.Vector {
+	return m.Vector{
+		X: a.X + (b.X-a.X)*t,
+		Y: a.Y + (b.Y-a.Y)*t,
+		Z: a.Z + (b.Z-a.Z)*t,
+		W: a.W + (b.W-a.W)*t,
+	}
+}
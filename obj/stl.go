@@ -0,0 +1,259 @@
+package obj
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	m "go-3d-rasterizer/math3d"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+)
+
+// STLFormat selects the encoding of an stl file
+type STLFormat int
+
+// stl formats
+const (
+	STLBinary STLFormat = iota
+	STLASCII
+)
+
+const (
+	stlHeaderSize   = 80
+	stlTriangleSize = 50 // normal, 3 vertices and the attribute byte count
+)
+
+// stlLoader holds the state of the stl importer
+type stlLoader struct {
+	diagnostics
+	file     string
+	model    *Model
+	vertices map[[3]float32]int // stl files repeat the vertices for every facet, they are welded by their position
+}
+
+// ParseSTL loads an ascii or binary stl file. Vertices with the same position are merged and the facet normals are
+// used for all corners of a facet, so the model is shaded flat unless GenerateNormals is called.
+// Every solid of an ascii file becomes a group, all faces use a gray default material
+func ParseSTL(filename string) (*Model, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	l := &stlLoader{
+		file:     filename,
+		model:    &Model{sampler: DefaultSampler, materialMap: make(map[string]int)},
+		vertices: make(map[[3]float32]int),
+	}
+	l.model.addDefaultMaterial() // stl files have no materials
+	// binary files may start with "solid" as well, the size tells them apart
+	isBinary := !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("solid"))
+	if len(data) >= stlHeaderSize+4 {
+		count := int(binary.LittleEndian.Uint32(data[stlHeaderSize:]))
+		isBinary = isBinary || len(data) == stlHeaderSize+4+count*stlTriangleSize
+	}
+	if isBinary {
+		err = l.parseBinary(data)
+	} else {
+		err = l.parseASCII(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.model.warnings = l.warnings
+	return l.model, nil
+}
+
+func (l *stlLoader) parseBinary(data []byte) error {
+	if len(data) < stlHeaderSize+4 {
+		return &ParseError{File: l.file, Msg: "binary stl header is truncated"}
+	}
+	count := int(binary.LittleEndian.Uint32(data[stlHeaderSize:]))
+	data = data[stlHeaderSize+4:]
+	if len(data) < count*stlTriangleSize {
+		return &ParseError{File: l.file, Msg: fmt.Sprintf("binary stl is truncated, expected %d triangles, got %d", count, len(data)/stlTriangleSize)}
+	}
+
+	g := l.model.addGroup("", "default")
+	var values [12]float64
+	for i := 0; i < count; i++ {
+		tri := data[i*stlTriangleSize:]
+		for j := range values {
+			values[j] = float64(math.Float32frombits(binary.LittleEndian.Uint32(tri[j*4:])))
+		}
+		normal := m.Vector{X: values[0], Y: values[1], Z: values[2], W: 1}
+		corners := []m.Vector{
+			{X: values[3], Y: values[4], Z: values[5], W: 1},
+			{X: values[6], Y: values[7], Z: values[8], W: 1},
+			{X: values[9], Y: values[10], Z: values[11], W: 1},
+		}
+		l.addFacet(g, normal, corners)
+	}
+	return nil
+}
+
+// parseASCII parses the keywords of an ascii stl file
+// source: https://en.wikipedia.org/wiki/STL_(file_format)#ASCII_STL
+func (l *stlLoader) parseASCII(r io.Reader) error {
+	t := newTokenizer(r, l.file)
+	var g *Group // created with the first facet of a solid
+	groupName := "default"
+	var normal m.Vector
+	var corners []m.Vector
+	inFacet := false
+	for t.next() {
+		keyword := t.tokens[0]
+		switch keyword.text {
+		case "solid":
+			groupName = "default"
+			if len(t.tokens) > 1 {
+				groupName = t.rest(1)
+			}
+			g = nil
+		case "facet":
+			corners = corners[:0]
+			inFacet = true
+			// facets without a valid normal get the normal of their vertices
+			normal = m.Vector{}
+			if len(t.tokens) != 5 || t.tokens[1].text != "normal" {
+				if err := l.report(t.errorAt(keyword, "expected \"facet normal x y z\"")); err != nil {
+					return err
+				}
+				continue
+			}
+			v, err := t.parseFloats(&l.diagnostics, 2, 3)
+			if err != nil {
+				return err
+			}
+			normal = m.Vector{X: v[0], Y: v[1], Z: v[2], W: 1}
+		case "vertex":
+			if !inFacet {
+				return t.errorAt(keyword, "vertex outside of a facet")
+			}
+			if len(t.tokens) != 4 {
+				if err := l.report(t.errorAt(keyword, "vertex expects 3 arguments, got %d", len(t.tokens)-1)); err != nil {
+					return err
+				}
+				continue
+			}
+			v, err := t.parseFloats(&l.diagnostics, 1, 3)
+			if err != nil {
+				return err
+			}
+			corners = append(corners, m.Vector{X: v[0], Y: v[1], Z: v[2], W: 1})
+		case "endfacet":
+			if !inFacet {
+				return t.errorAt(keyword, "endfacet outside of a facet")
+			}
+			inFacet = false
+			if len(corners) < 3 {
+				if err := l.report(t.errorAt(keyword, "facet with %d vertices skipped", len(corners))); err != nil {
+					return err
+				}
+				continue
+			}
+			if g == nil {
+				g = l.model.addGroup("", groupName)
+			}
+			l.addFacet(g, normal, corners)
+		case "outer", "endloop":
+		case "endsolid":
+			g, groupName = nil, "default"
+		default:
+			if err := l.report(t.errorAt(keyword, "unknown keyword %q", keyword.text)); err != nil {
+				return err
+			}
+		}
+	}
+	return t.err()
+}
+
+// addFacet adds a facet as a face, facets without a valid normal get the normal of their vertices
+func (l *stlLoader) addFacet(g *Group, normal m.Vector, corners []m.Vector) {
+	o := l.model
+	f := face{material: 0, hasNormals: true}
+	for _, v := range corners {
+		key := [3]float32{float32(v.X), float32(v.Y), float32(v.Z)}
+		idx, ok := l.vertices[key]
+		if !ok {
+			idx = len(o.vertices)
+			l.vertices[key] = idx
+			o.vertices = append(o.vertices, v)
+		}
+		f.corners = append(f.corners, corner{v: idx, t: -1, n: len(o.normals), tg: -1})
+	}
+	if m.Magnitude(normal) == 0 || math.IsNaN(m.Magnitude(normal)) {
+		normal, _ = o.faceNormal(f)
+	} else {
+		normal = m.Normalize(normal)
+		normal.W = 1
+	}
+	o.normals = append(o.normals, normal)
+
+	firstTriangle := len(o.triangles)
+	o.addFace(f)
+	g.addFace(firstTriangle)
+}
+
+// WriteSTL writes the triangles of the model into an stl file. The facet normals are calculated from the vertices,
+// in ascii files every group is written as a separate solid
+func (o *Model) WriteSTL(w io.Writer, format STLFormat) error {
+	bw := bufio.NewWriter(w)
+	if format == STLBinary {
+		header := make([]byte, stlHeaderSize+4)
+		copy(header, "binary stl written by go-3d-rasterizer")
+		binary.LittleEndian.PutUint32(header[stlHeaderSize:], uint32(len(o.triangles)))
+		bw.Write(header)
+		buf := make([]byte, stlTriangleSize)
+		for i := range o.triangles {
+			normal, corners := o.triangleFacet(i)
+			for j, v := range append([]m.Vector{normal}, corners[:]...) {
+				binary.LittleEndian.PutUint32(buf[j*12:], math.Float32bits(float32(v.X)))
+				binary.LittleEndian.PutUint32(buf[j*12+4:], math.Float32bits(float32(v.Y)))
+				binary.LittleEndian.PutUint32(buf[j*12+8:], math.Float32bits(float32(v.Z)))
+			}
+			bw.Write(buf)
+		}
+		return bw.Flush()
+	}
+
+	for _, g := range o.groups {
+		fmt.Fprintf(bw, "solid %s\n", g.Name)
+		for _, r := range g.ranges {
+			for i := r.firstTriangle; i < r.lastTriangle; i++ {
+				normal, corners := o.triangleFacet(i)
+				fmt.Fprintf(bw, "  facet normal %s\n    outer loop\n", stlVector(normal))
+				for _, v := range corners {
+					fmt.Fprintf(bw, "      vertex %s\n", stlVector(v))
+				}
+				fmt.Fprintf(bw, "    endloop\n  endfacet\n")
+			}
+		}
+		fmt.Fprintf(bw, "endsolid %s\n", g.Name)
+	}
+	return bw.Flush()
+}
+
+// triangleFacet returns the normal and the vertices of a triangle
+func (o *Model) triangleFacet(i int) (m.Vector, [3]m.Vector) {
+	t := o.triangles[i]
+	f := o.faces[t.face]
+	var corners [3]m.Vector
+	for k, c := range t.corners {
+		corners[k] = o.vertices[f.corners[c].v]
+	}
+	normal := m.Cross(m.Sub(corners[1], corners[0]), m.Sub(corners[2], corners[0]))
+	if m.Magnitude(normal) > 0 {
+		normal = m.Normalize(normal)
+	}
+	return normal, corners
+}
+
+// stlVector formats the components of a vector in the exponential notation, with the precision of float32
+func stlVector(v m.Vector) string {
+	return strconv.FormatFloat(float64(float32(v.X)), 'e', -1, 32) + " " +
+		strconv.FormatFloat(float64(float32(v.Y)), 'e', -1, 32) + " " +
+		strconv.FormatFloat(float64(float32(v.Z)), 'e', -1, 32)
+}
@@ -0,0 +1,166 @@
+package obj
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	m "go-3d-rasterizer/math3d"
+)
+
+// squareSTL is a square made of two facets which share an edge, followed by a single facet in a second solid
+const squareSTL = `solid square
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 1 1 0
+    endloop
+  endfacet
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1 1 0
+      vertex 0 1 0
+    endloop
+  endfacet
+endsolid square
+solid side
+  facet normal 0 -1 0
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 0 0 1
+    endloop
+  endfacet
+endsolid side
+`
+
+// parseSTLData writes the data to a temporary file and parses it
+func parseSTLData(t *testing.T, data []byte) *Model {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "model.stl")
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	model, err := ParseSTL(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return model
+}
+
+// triangleCorners returns the positions of the triangle corners
+func triangleCorners(o *Model) [][3]m.Vector {
+	var ret [][3]m.Vector
+	for i := range o.triangles {
+		_, corners := o.triangleFacet(i)
+		ret = append(ret, corners)
+	}
+	return ret
+}
+
+// groupNames returns the names of the groups
+func groupNames(o *Model) []string {
+	var ret []string
+	for _, g := range o.groups {
+		ret = append(ret, g.Name)
+	}
+	return ret
+}
+
+func TestParseSTLWeldsVertices(t *testing.T) {
+	model := parseSTLData(t, []byte(squareSTL))
+	if len(model.Warnings()) > 0 {
+		t.Errorf("unexpected warnings %v", model.Warnings())
+	}
+	// the 9 corners share 5 positions
+	if len(model.vertices) != 5 {
+		t.Errorf("%d vertices, want 5", len(model.vertices))
+	}
+	if len(model.faces) != 3 || len(model.normals) != 3 {
+		t.Fatalf("%d faces with %d normals, want 3", len(model.faces), len(model.normals))
+	}
+	if got, want := model.faces[1].corners[0].v, model.faces[0].corners[0].v; got != want {
+		t.Errorf("shared corner references vertex %d, want %d", got, want)
+	}
+	if want := (m.Vector{X: 0, Y: -1, Z: 0, W: 1}); model.normals[2] != want {
+		t.Errorf("normal of the third facet = %v, want %v", model.normals[2], want)
+	}
+	if got, want := groupNames(model), []string{"square", "side"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("groups = %q, want %q", got, want)
+	}
+}
+
+func TestWriteSTLRoundTrip(t *testing.T) {
+	want := parseSTLData(t, []byte(squareSTL))
+	for _, format := range []STLFormat{STLASCII, STLBinary} {
+		var buf bytes.Buffer
+		if err := want.WriteSTL(&buf, format); err != nil {
+			t.Fatal(err)
+		}
+		got := parseSTLData(t, buf.Bytes())
+		if !reflect.DeepEqual(got.vertices, want.vertices) {
+			t.Errorf("format %d: vertices = %v, want %v", format, got.vertices, want.vertices)
+		}
+		if !reflect.DeepEqual(triangleCorners(got), triangleCorners(want)) {
+			t.Errorf("format %d: triangles = %v, want %v", format, triangleCorners(got), triangleCorners(want))
+		}
+		if !reflect.DeepEqual(got.normals, want.normals) {
+			t.Errorf("format %d: normals = %v, want %v", format, got.normals, want.normals)
+		}
+		// binary files have no solids
+		wantGroups := []string{"default"}
+		if format == STLASCII {
+			wantGroups = groupNames(want)
+		}
+		if names := groupNames(got); !reflect.DeepEqual(names, wantGroups) {
+			t.Errorf("format %d: groups = %q, want %q", format, names, wantGroups)
+		}
+	}
+}
+
+func TestParseSTLBinaryWithSolidHeader(t *testing.T) {
+	data := make([]byte, stlHeaderSize+4+stlTriangleSize)
+	copy(data, "solid exported by a tool which ignores the specification")
+	binary.LittleEndian.PutUint32(data[stlHeaderSize:], 1)
+	values := []float32{0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 1, 0}
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[stlHeaderSize+4+i*4:], math.Float32bits(v))
+	}
+	model := parseSTLData(t, data)
+	if len(model.Warnings()) > 0 {
+		t.Errorf("unexpected warnings %v", model.Warnings())
+	}
+	want := [][3]m.Vector{{{X: 0, Y: 0, Z: 0, W: 1}, {X: 1, Y: 0, Z: 0, W: 1}, {X: 0, Y: 1, Z: 0, W: 1}}}
+	if got := triangleCorners(model); !reflect.DeepEqual(got, want) {
+		t.Errorf("triangles = %v, want %v", got, want)
+	}
+}
+
+func TestParseSTLInvalidLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		stl      string
+		faces    int
+		warnings int
+	}{
+		{"facet without normal", "solid\nfacet\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nvertex 0 1 0\nendloop\nendfacet\nendsolid\n", 1, 1},
+		{"facet with a short normal", "solid\nfacet normal 0 1\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nvertex 0 1 0\nendloop\nendfacet\nendsolid\n", 1, 1},
+		{"vertex with 2 coordinates", "solid\nfacet normal 0 0 1\nouter loop\nvertex 0 0\nvertex 1 0 0\nvertex 0 1 0\nvertex 1 1 0\nendloop\nendfacet\nendsolid\n", 1, 1},
+		{"facet with too few vertices", "solid\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 1 0\nvertex 0 1 0\nendloop\nendfacet\nendsolid\n", 0, 2},
+	}
+	for _, tt := range tests {
+		model := parseSTLData(t, []byte(tt.stl))
+		if len(model.faces) != tt.faces {
+			t.Errorf("%s: %d faces, want %d", tt.name, len(model.faces), tt.faces)
+		}
+		if len(model.Warnings()) != tt.warnings {
+			t.Errorf("%s: warnings = %v, want %d", tt.name, model.Warnings(), tt.warnings)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package obj
+
+import (
+	m "go-3d-rasterizer/math3d"
+	"math"
+)
+
+// HasTangents reports whether every face with normals and texture coordinates has tangents
+func (o *Model) HasTangents() bool {
+	found := false
+	for _, f := range o.faces {
+		if f.hasNormals && f.hasTexture {
+			if !f.hasTangents {
+				return false
+			}
+			found = true
+		}
+	}
+	return found
+}
+
+// GenerateTangents calculates a tangent for every corner of the faces which have normals and texture coordinates,
+// they are needed to apply tangent space normal maps.
+// The tangents follow the conventions of MikkTSpace, which is used by most tools to bake normal maps:
+// the tangent points along the s texture coordinate, it is averaged over all triangles sharing the same position, normal
+// and texture coordinate weighted by the angle of the triangle and orthogonalized against the vertex normal.
+// W holds the sign of the bitangent, which is calculated in the shader as W * cross(normal, tangent)
+// source: http://www.mikktspace.com/
+func (o *Model) GenerateTangents() {
+	type vertexKey struct {
+		v, t, n int
+		flipped bool
+	}
+	sums := make(map[vertexKey]m.Vector)
+	bitangents := make(map[vertexKey]m.Vector)
+	cornerKey := func(c corner, flipped bool) vertexKey {
+		return vertexKey{v: c.v, t: c.t, n: c.n, flipped: flipped}
+	}
+
+	// per triangle tangent frames, accumulated at their corners
+	triFlipped := make([]bool, len(o.triangles))
+	for i, tri := range o.triangles {
+		f := o.faces[tri.face]
+		if !f.hasNormals || !f.hasTexture {
+			continue
+		}
+		var c [3]corner
+		for k := range c {
+			c[k] = f.corners[tri.corners[k]]
+		}
+		tangent, bitangent, ok := o.triangleTangent(c)
+		if !ok {
+			continue
+		}
+		triFlipped[i] = m.Dot(m.Cross(o.normals[c[0].n], tangent), bitangent) < 0
+		for k := range c {
+			n := o.normals[c[k].n]
+			t := orthogonalize(tangent, n)
+			b := orthogonalize(bitangent, n)
+			weight := triangleAngle(o.vertices[c[k].v], o.vertices[c[(k+1)%3].v], o.vertices[c[(k+2)%3].v])
+			key := cornerKey(c[k], triFlipped[i])
+			sums[key] = m.Add(sums[key], m.Mul(t, weight))
+			bitangents[key] = m.Add(bitangents[key], m.Mul(b, weight))
+		}
+	}
+
+	o.tangents = o.tangents[:0]
+	for i := range o.faces {
+		f := &o.faces[i]
+		f.hasTangents = false
+		for c := range f.corners {
+			f.corners[c].tg = -1
+		}
+	}
+	for i, tri := range o.triangles {
+		f := &o.faces[tri.face]
+		if !f.hasNormals || !f.hasTexture {
+			continue
+		}
+		for _, fc := range tri.corners {
+			c := &f.corners[fc]
+			if c.tg != -1 {
+				continue
+			}
+			key := cornerKey(*c, triFlipped[i])
+			n := o.normals[c.n]
+			tangent := orthogonalize(sums[key], n)
+			if m.Magnitude(tangent) == 0 {
+				// degenerate texture coordinates, any tangent works
+				tangent = m.Normalize(perpendicular(n))
+			}
+			tangent.W = 1
+			if m.Dot(m.Cross(n, tangent), bitangents[key]) < 0 {
+				tangent.W = -1
+			}
+			c.tg = len(o.tangents)
+			o.tangents = append(o.tangents, tangent)
+		}
+		f.hasTangents = true
+	}
+}
+
+// triangleTangent calculates the unnormalized tangent and bitangent of a triangle, which are the derivatives
+// of the position with respect to the s and t texture coordinate
+func (o *Model) triangleTangent(c [3]corner) (m.Vector, m.Vector, bool) {
+	p0, p1, p2 := o.vertices[c[0].v], o.vertices[c[1].v], o.vertices[c[2].v]
+	uv0, uv1, uv2 := o.texCoords[c[0].t], o.texCoords[c[1].t], o.texCoords[c[2].t]
+	e1, e2 := m.Sub(p1, p0), m.Sub(p2, p0)
+	ds1, dt1 := uv1.s-uv0.s, uv1.t-uv0.t
+	ds2, dt2 := uv2.s-uv0.s, uv2.t-uv0.t
+	r := ds1*dt2 - ds2*dt1
+	if r == 0 {
+		return m.Vector{}, m.Vector{}, false
+	}
+	tangent := m.Mul(m.Sub(m.Mul(e1, dt2), m.Mul(e2, dt1)), 1/r)
+	bitangent := m.Mul(m.Sub(m.Mul(e2, ds1), m.Mul(e1, ds2)), 1/r)
+	return tangent, bitangent, true
+}
+
+// triangleAngle calculates the angle of a triangle at the corner a
+func triangleAngle(a, b, c m.Vector) float64 {
+	u, v := m.Sub(b, a), m.Sub(c, a)
+	if m.Magnitude(u) == 0 || m.Magnitude(v) == 0 {
+		return 0
+	}
+	return math.Acos(math.Max(-1, math.Min(1, m.Dot(m.Normalize(u), m.Normalize(v)))))
+}
@@ -0,0 +1,137 @@
+package obj
+
+import (
+	"errors"
+	m "go-3d-rasterizer/math3d"
+	"image"
+	"image/draw"
+	_ "image/jpeg" // register the jpeg decoder for textures
+	_ "image/png"  // register the png decoder for textures
+	"io"
+	"os"
+)
+
+// Texture holds the pixels of a texture map and its mip chain
+type Texture struct {
+	Filename string // path of the image file, or its name within the resolver of the model
+	img      *image.NRGBA
+	levels   []*image.NRGBA // levels[0] is img, every following level has half the size of the previous one
+	opaque   bool
+}
+
+// NewTexture creates a texture from an image and builds its mip chain. Images without pixels are replaced by
+// a single white pixel, so they can be sampled
+func NewTexture(img image.Image) *Texture {
+	if img.Bounds().Empty() {
+		white := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+		copy(white.Pix, []uint8{255, 255, 255, 255})
+		img = white
+	}
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok || nrgba.Rect.Min != (image.Point{}) {
+		bounds := img.Bounds()
+		nrgba = image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(nrgba, nrgba.Rect, img, bounds.Min, draw.Src)
+	}
+	return &Texture{img: nrgba, levels: buildMipChain(nrgba), opaque: nrgba.Opaque()}
+}
+
+// buildMipChain downsamples the image with a 2x2 box filter of the premultiplied colors until it is 1x1 pixels big
+func buildMipChain(img *image.NRGBA) []*image.NRGBA {
+	levels := []*image.NRGBA{img}
+	for {
+		prev := levels[len(levels)-1]
+		w, h := prev.Rect.Dx(), prev.Rect.Dy()
+		if w <= 1 && h <= 1 {
+			return levels
+		}
+		nw, nh := (w+1)/2, (h+1)/2
+		level := image.NewNRGBA(image.Rect(0, 0, nw, nh))
+		for y := 0; y < nh; y++ {
+			y0, y1 := 2*y, 2*y+1
+			if y1 >= h {
+				y1 = y0
+			}
+			for x := 0; x < nw; x++ {
+				x0, x1 := 2*x, 2*x+1
+				if x1 >= w {
+					x1 = x0
+				}
+				src := [4][]uint8{
+					prev.Pix[prev.PixOffset(x0, y0):], prev.Pix[prev.PixOffset(x1, y0):],
+					prev.Pix[prev.PixOffset(x0, y1):], prev.Pix[prev.PixOffset(x1, y1):],
+				}
+				// the colors are premultiplied by alpha before they are averaged, otherwise the colors of
+				// transparent pixels bleed into the visible ones. Fully transparent pixels keep their average color
+				alpha := int(src[0][3]) + int(src[1][3]) + int(src[2][3]) + int(src[3][3])
+				dst := level.Pix[level.PixOffset(x, y):]
+				for c := 0; c < 3; c++ {
+					sum, premultiplied := 0, 0
+					for _, p := range src {
+						sum += int(p[c])
+						premultiplied += int(p[c]) * int(p[3])
+					}
+					if alpha == 0 {
+						dst[c] = uint8((sum + 2) / 4)
+					} else {
+						dst[c] = uint8((premultiplied + alpha/2) / alpha)
+					}
+				}
+				dst[3] = uint8((alpha + 2) / 4)
+			}
+		}
+		levels = append(levels, level)
+	}
+}
+
+// LoadTexture loads a png or jpeg texture from a file
+func LoadTexture(filename string) (*Texture, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return decodeTexture(file, filename)
+}
+
+// decodeTexture decodes a png or jpeg texture, filename is stored in the texture. Images without pixels are errors
+func decodeTexture(r io.Reader, filename string) (*Texture, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	if img.Bounds().Empty() {
+		return nil, errors.New("image has no pixels")
+	}
+	tex := NewTexture(img)
+	tex.Filename = filename
+	return tex, nil
+}
+
+// Image returns the image of the texture
+func (t *Texture) Image() image.Image {
+	return t.img
+}
+
+// Width returns the width of the texture in pixels
+func (t *Texture) Width() int {
+	return t.img.Rect.Dx()
+}
+
+// Height returns the height of the texture in pixels
+func (t *Texture) Height() int {
+	return t.img.Rect.Dy()
+}
+
+// Levels returns the number of mip levels
+func (t *Texture) Levels() int {
+	return len(t.levels)
+}
+
+// texel returns the color of the pixel x, y of a mip level, with y = 0 being the bottom row of the image
+func (t *Texture) texel(level, x, y int) m.Vector {
+	img := t.levels[level]
+	i := img.PixOffset(x, img.Rect.Dy()-1-y)
+	p := img.Pix[i : i+4 : i+4]
+	return m.Vector{X: float64(p[0]) / 255., Y: float64(p[1]) / 255., Z: float64(p[2]) / 255., W: float64(p[3]) / 255.}
+}
@@ -0,0 +1,54 @@
+package obj
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewTextureEmptyImage(t *testing.T) {
+	tex := NewTexture(image.NewNRGBA(image.Rect(0, 0, 0, 0)))
+	if tex.Width() != 1 || tex.Height() != 1 || tex.Levels() != 1 {
+		t.Fatalf("texture is %dx%d with %d levels, want 1x1 with 1 level", tex.Width(), tex.Height(), tex.Levels())
+	}
+	if c := tex.texel(0, 0, 0); c.X != 1 || c.Y != 1 || c.Z != 1 || c.W != 1 {
+		t.Errorf("texel = %v, want white", c)
+	}
+}
+
+func TestLoadTextureErrors(t *testing.T) {
+	if _, err := LoadTexture("testdata/missing.png"); !os.IsNotExist(err) {
+		t.Errorf("missing file: error %v, want not exist", err)
+	}
+	if _, err := decodeTexture(strings.NewReader("no image"), "broken.png"); err == nil {
+		t.Error("invalid image: no error")
+	}
+}
+
+func TestMipChainPremultipliedAlpha(t *testing.T) {
+	tests := []struct {
+		name   string
+		pixels [4]color.NRGBA
+		want   color.NRGBA
+	}{
+		{"opaque", [4]color.NRGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {255, 255, 255, 255}}, color.NRGBA{128, 128, 128, 255}},
+		{"transparent pixels do not bleed", [4]color.NRGBA{{255, 0, 0, 255}, {0, 0, 0, 0}, {0, 255, 0, 0}, {0, 0, 0, 0}}, color.NRGBA{255, 0, 0, 64}},
+		{"weighted by alpha", [4]color.NRGBA{{255, 0, 0, 192}, {0, 0, 255, 64}, {0, 0, 0, 0}, {0, 0, 0, 0}}, color.NRGBA{191, 0, 64, 64}},
+		{"fully transparent", [4]color.NRGBA{{255, 0, 0, 0}, {0, 0, 255, 0}, {255, 0, 0, 0}, {0, 0, 255, 0}}, color.NRGBA{128, 0, 128, 0}},
+	}
+	for _, tt := range tests {
+		img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+		for i, c := range tt.pixels {
+			img.SetNRGBA(i%2, i/2, c)
+		}
+		levels := buildMipChain(img)
+		if len(levels) != 2 {
+			t.Fatalf("%s: %d levels, want 2", tt.name, len(levels))
+		}
+		if got := levels[1].NRGBAAt(0, 0); got != tt.want {
+			t.Errorf("%s: mip level color = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseError describes a problem in a model file, it is used for errors and warnings.
+// Line and Column are 0 for formats which are not line based, File is empty for models parsed from a reader
+type ParseError struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	pos := e.File
+	if e.Line != 0 {
+		pos = fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+		if e.File == "" {
+			pos = fmt.Sprintf("%d:%d", e.Line, e.Column)
+		}
+	}
+	if pos == "" {
+		return e.Msg
+	}
+	return pos + ": " + e.Msg
+}
+
+// ParseOptions configures the parser
+type ParseOptions struct {
+	// Strict turns invalid indices and numbers into errors, otherwise they are reported as warnings
+	// and the affected face is skipped or the value is replaced by 0
+	Strict bool
+	// Resolver opens the material libraries and textures referenced by the model
+	Resolver Resolver
+	// BumpAsNormalMap loads map_Bump and bump textures as tangent space normal maps instead of height maps.
+	// Blender exports normal maps as map_Bump, other programs use norm
+	BumpAsNormalMap bool
+}
+
+// token is a whitespace separated word of a line
+type token struct {
+	text   string
+	line   int
+	column int
+}
+
+// tokenizer splits a file into logical lines of tokens.
+// Comments (starting with #) are removed and lines ending with a backslash are joined with the following line
+type tokenizer struct {
+	file    string
+	scanner *bufio.Scanner
+	line    int
+	tokens  []token
+}
+
+func newTokenizer(r io.Reader, file string) *tokenizer {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &tokenizer{file: file, scanner: scanner}
+}
+
+// next reads the next logical line which is not empty, it returns false at the end of the file
+func (t *tokenizer) next() bool {
+	t.tokens = t.tokens[:0]
+	for t.scanner.Scan() {
+		t.line++
+		text := t.scanner.Text()
+		if idx := strings.IndexByte(text, '#'); idx >= 0 {
+			text = text[:idx]
+		}
+		text = strings.TrimRight(text, " \t\r")
+		continued := strings.HasSuffix(text, "\\")
+		if continued {
+			text = text[:len(text)-1]
+		}
+		t.split(text)
+		if !continued && len(t.tokens) > 0 {
+			return true
+		}
+	}
+	return len(t.tokens) > 0
+}
+
+// split appends the tokens of a physical line
+func (t *tokenizer) split(text string) {
+	start := -1
+	for i := 0; i <= len(text); i++ {
+		isSpace := i == len(text) || text[i] == ' ' || text[i] == '\t' || text[i] == '\r' || text[i] == '\f' || text[i] == '\v'
+		if !isSpace && start == -1 {
+			start = i
+		} else if isSpace && start != -1 {
+			t.tokens = append(t.tokens, token{text: text[start:i], line: t.line, column: start + 1})
+			start = -1
+		}
+	}
+}
+
+// err returns the error of the underlying reader
+func (t *tokenizer) err() error {
+	return t.scanner.Err()
+}
+
+// errorAt creates a parse error located at a token
+func (t *tokenizer) errorAt(tok token, format string, args ...interface{}) *ParseError {
+	return &ParseError{File: t.file, Line: tok.line, Column: tok.column, Msg: fmt.Sprintf(format, args...)}
+}
+
+// rest joins all tokens starting at index i, it is used for names which may contain spaces
+func (t *tokenizer) rest(i int) string {
+	parts := make([]string, 0, len(t.tokens)-i)
+	for _, tok := range t.tokens[i:] {
+		parts = append(parts, tok.text)
+	}
+	return strings.Join(parts, " ")
+}
+
+// diagnostics collects warnings, in strict mode reportable problems are turned into errors
+type diagnostics struct {
+	strict   bool
+	warnings []*ParseError
+}
+
+// warn records a warning, independent of the mode
+func (d *diagnostics) warn(err *ParseError) {
+	d.warnings = append(d.warnings, err)
+}
+
+// report returns the error in strict mode, otherwise it is recorded as a warning and nil is returned
+func (d *diagnostics) report(err *ParseError) error {
+	if d.strict {
+		return err
+	}
+	d.warn(err)
+	return nil
+}
+
+// parseFloats parses the tokens of the current line starting at index first. Invalid numbers are reported
+// and replaced by 0, the returned slice always has the length of count
+func (t *tokenizer) parseFloats(d *diagnostics, first, count int) ([]float64, error) {
+	ret := make([]float64, count)
+	for i := range ret {
+		tok := t.tokens[first+i]
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			if err := d.report(t.errorAt(tok, "invalid number %q", tok.text)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		ret[i] = v
+	}
+	return ret, nil
+}
@@ -0,0 +1,97 @@
+package obj
+
+import (
+	m "go-3d-rasterizer/math3d"
+	"math"
+)
+
+// triangulate splits a face into triangles. Faces with more than three corners are projected onto their best-fit plane
+// and triangulated with the ear clipping algorithm, which also handles concave polygons
+// source: https://www.geometrictools.com/Documentation/TriangulationByEarClipping.pdf
+func (o *Model) triangulate(faceIdx int) {
+	f := o.faces[faceIdx]
+	count := len(f.corners)
+	if count == 3 {
+		o.triangles = append(o.triangles, triangle{face: faceIdx, corners: [3]int{0, 1, 2}})
+		return
+	}
+
+	normal, area := o.faceNormal(f)
+	points := make([]point2d, count)
+	if area > 0 {
+		// orthonormal basis of the plane, the polygon is counter clockwise in it
+		axisU := m.Normalize(perpendicular(normal))
+		axisV := m.Cross(normal, axisU)
+		for i, c := range f.corners {
+			p := o.vertices[c.v]
+			points[i] = point2d{m.Dot(p, axisU), m.Dot(p, axisV)}
+		}
+	}
+
+	remaining := make([]int, count)
+	for i := range remaining {
+		remaining[i] = i
+	}
+	for len(remaining) > 3 {
+		ear := -1
+		if area > 0 {
+			ear = findEar(points, remaining)
+		}
+		if ear == -1 {
+			break // degenerate polygon, fall back to a triangle fan
+		}
+		n := len(remaining)
+		prev, cur, next := remaining[(ear+n-1)%n], remaining[ear], remaining[(ear+1)%n]
+		o.triangles = append(o.triangles, triangle{face: faceIdx, corners: [3]int{prev, cur, next}})
+		remaining = append(remaining[:ear], remaining[ear+1:]...)
+	}
+	for i := 1; i < len(remaining)-1; i++ {
+		o.triangles = append(o.triangles, triangle{face: faceIdx, corners: [3]int{remaining[0], remaining[i], remaining[i+1]}})
+	}
+}
+
+type point2d struct {
+	x, y float64
+}
+
+// cross2d returns twice the signed area of the triangle a, b, c, which is positive if it is counter clockwise
+func cross2d(a, b, c point2d) float64 {
+	return (b.x-a.x)*(c.y-a.y) - (b.y-a.y)*(c.x-a.x)
+}
+
+// findEar returns the position of a convex corner in remaining, whose triangle does not contain any other corner
+func findEar(points []point2d, remaining []int) int {
+	n := len(remaining)
+	for i := range remaining {
+		a, b, c := points[remaining[(i+n-1)%n]], points[remaining[i]], points[remaining[(i+1)%n]]
+		if cross2d(a, b, c) <= 0 {
+			continue // reflex or collinear
+		}
+		isEar := true
+		for j := range remaining {
+			if j == i || j == (i+n-1)%n || j == (i+1)%n {
+				continue
+			}
+			p := points[remaining[j]]
+			if cross2d(a, b, p) >= 0 && cross2d(b, c, p) >= 0 && cross2d(c, a, p) >= 0 {
+				isEar = false
+				break
+			}
+		}
+		if isEar {
+			return i
+		}
+	}
+	return -1
+}
+
+// perpendicular returns a vector which is perpendicular to v
+func perpendicular(v m.Vector) m.Vector {
+	if math.Abs(v.X) < math.Abs(v.Y) && math.Abs(v.X) < math.Abs(v.Z) {
+		return m.Cross(v, m.Vector{X: 1, Y: 0, Z: 0, W: 1})
+	}
+	if math.Abs(v.Y) < math.Abs(v.Z) {
+		return m.Cross(v, m.Vector{X: 0, Y: 1, Z: 0, W: 1})
+	}
+	return m.Cross(v, m.Vector{X: 0, Y: 0, Z: 1, W: 1})
+}
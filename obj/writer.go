@@ -0,0 +1,281 @@
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	m "go-3d-rasterizer/math3d"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WriteOptions configures how a model is written by WriteOBJWithOptions
+type WriteOptions struct {
+	// MaterialLibrary is the name of the .mtl file written by WriteMTL, it is referenced by a mtllib statement.
+	// The statement is left out if it is empty
+	MaterialLibrary string
+}
+
+// WriteOBJ writes the model as an .obj file. Faces keep their original polygons and order, every group is written
+// with its object and name. Materials are referenced by name, the material library is written with WriteMTL.
+// Vertex colors are written as "v x y z r g b"
+func (o *Model) WriteOBJ(w io.Writer) error {
+	return o.WriteOBJWithOptions(w, WriteOptions{})
+}
+
+// WriteOBJWithOptions writes the model as an .obj file like WriteOBJ, the options name the material library
+func (o *Model) WriteOBJWithOptions(w io.Writer, opts WriteOptions) error {
+	return o.writeOBJ(w, opts.MaterialLibrary)
+}
+
+// SaveOBJ writes the model into an .obj file and its materials into an .mtl file with the same name next to it.
+// Texture paths are written relative to the directory of the files
+func (o *Model) SaveOBJ(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	mtllib := ""
+	if len(o.materials) > 0 {
+		mtlFilename := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".mtl"
+		mtllib = filepath.Base(mtlFilename)
+		if err := o.saveMTL(mtlFilename); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := o.writeOBJ(file, mtllib); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (o *Model) saveMTL(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := o.writeMTL(file, filepath.Dir(filename)); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// writeOBJ writes the model, mtllib is the name of the material library, it is left out if empty
+func (o *Model) writeOBJ(w io.Writer, mtllib string) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# written by go-3d-rasterizer\n")
+	if mtllib != "" {
+		fmt.Fprintf(bw, "mtllib %s\n", mtllib)
+	}
+	for i, v := range o.vertices {
+		values := []float64{v.X, v.Y, v.Z}
+		if v.W != 1 {
+			values = append(values, v.W)
+		}
+		if len(o.colors) > 0 {
+			c := o.colors[i]
+			values = append(values, c.X, c.Y, c.Z)
+		}
+		fmt.Fprintf(bw, "v %s\n", formatFloats(values...))
+	}
+	for _, st := range o.texCoords {
+		fmt.Fprintf(bw, "vt %s\n", formatFloats(st.s, st.t))
+	}
+	for _, n := range o.normals {
+		fmt.Fprintf(bw, "vn %s\n", formatFloats(n.X, n.Y, n.Z))
+	}
+
+	// the face ranges are written in the order of the faces, groups which are continued later in the file
+	// are continued by the parser as well
+	type groupRange struct {
+		g *Group
+		r faceRange
+	}
+	var ranges []groupRange
+	for _, g := range o.groups {
+		for _, r := range g.ranges {
+			ranges = append(ranges, groupRange{g, r})
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].r.firstFace < ranges[j].r.firstFace })
+
+	// the parser starts without group, object, material and smoothing group.
+	// The obj format has no statement to reset the material, faces without one get the default material
+	var group *Group
+	object, material, smoothingGroup := "", "", 0
+	defaultName := o.defaultMaterialName()
+	for _, gr := range ranges {
+		if gr.g != group {
+			group = gr.g
+			if group.Object != object {
+				object = group.Object
+				fmt.Fprintf(bw, "o %s\n", object)
+			}
+			fmt.Fprintf(bw, "g %s\n", group.Name)
+		}
+		r := gr.r
+		name := defaultName
+		if r.material != -1 {
+			name = o.materials[r.material].name
+		}
+		if name != material {
+			material = name
+			fmt.Fprintf(bw, "usemtl %s\n", material)
+		}
+		for _, f := range o.faces[r.firstFace:r.lastFace] {
+			if o.hasSmoothingGroups && f.smoothingGroup != smoothingGroup {
+				smoothingGroup = f.smoothingGroup
+				if smoothingGroup == 0 {
+					fmt.Fprintf(bw, "s off\n")
+				} else {
+					fmt.Fprintf(bw, "s %d\n", smoothingGroup)
+				}
+			}
+			bw.WriteString("f")
+			for _, c := range f.corners {
+				switch {
+				case f.hasTexture && f.hasNormals:
+					fmt.Fprintf(bw, " %d/%d/%d", c.v+1, c.t+1, c.n+1)
+				case f.hasTexture:
+					fmt.Fprintf(bw, " %d/%d", c.v+1, c.t+1)
+				case f.hasNormals:
+					fmt.Fprintf(bw, " %d//%d", c.v+1, c.n+1)
+				default:
+					fmt.Fprintf(bw, " %d", c.v+1)
+				}
+			}
+			bw.WriteString("\n")
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteMTL writes the materials of the model as an .mtl file. Texture paths are written as they have been loaded,
+// textures which have not been loaded from a file are left out. Metallic-roughness materials are written with
+// their blinn-phong approximation
+func (o *Model) WriteMTL(w io.Writer) error {
+	return o.writeMTL(w, "")
+}
+
+// writeMTL writes the materials, texture paths are made relative to dir unless it is empty
+func (o *Model) writeMTL(w io.Writer, dir string) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# written by go-3d-rasterizer\n")
+	for _, mat := range o.materials {
+		diffuse, specular, exponent := mat.diffuseColor, mat.specularColor, mat.specularExponent
+		if mat.pbr {
+			diffuse, specular, exponent = mat.metallicRoughness(o.sampler, texLookup{}, false)
+		}
+		fmt.Fprintf(bw, "\nnewmtl %s\n", mat.name)
+		fmt.Fprintf(bw, "Ka %s\n", formatColor(mat.ambientColor))
+		fmt.Fprintf(bw, "Kd %s\n", formatColor(diffuse))
+		fmt.Fprintf(bw, "Ks %s\n", formatColor(specular))
+		fmt.Fprintf(bw, "Ke %s\n", formatColor(mat.emissiveColor))
+		fmt.Fprintf(bw, "Ns %s\n", formatFloats(exponent))
+		fmt.Fprintf(bw, "Ni %s\n", formatFloats(mat.opticalDensity))
+		fmt.Fprintf(bw, "d %s\n", formatFloats(mat.dissolve))
+		fmt.Fprintf(bw, "illum %d\n", mat.illum)
+		maps := []struct {
+			directive string
+			tm        *textureMap
+		}{
+			{"map_Kd", mat.mapKd},
+			{"map_Ks", mat.mapKs},
+			{"map_Ke", mat.mapKe},
+			{"map_d", mat.mapD},
+			{"bump", mat.bump},
+			{"norm", mat.normalMap},
+		}
+		for _, mp := range maps {
+			if mp.tm != nil && mp.tm.tex.Filename != "" {
+				fmt.Fprintf(bw, "%s %s\n", mp.directive, mp.tm.format(dir))
+			}
+		}
+	}
+	if name := o.defaultMaterialName(); name != "" {
+		mat := defaultMaterial(name)
+		fmt.Fprintf(bw, "\nnewmtl %s\n", mat.name)
+		fmt.Fprintf(bw, "Ka %s\n", formatColor(mat.ambientColor))
+		fmt.Fprintf(bw, "Kd %s\n", formatColor(mat.diffuseColor))
+		fmt.Fprintf(bw, "Ks %s\n", formatColor(mat.specularColor))
+		fmt.Fprintf(bw, "Ns %s\n", formatFloats(mat.specularExponent))
+		fmt.Fprintf(bw, "illum %d\n", mat.illum)
+	}
+	return bw.Flush()
+}
+
+// defaultMaterialName returns the name of the default material, which is written for faces without a material.
+// It is empty if the model has no materials or every face has one, the faces are written without material then
+func (o *Model) defaultMaterialName() string {
+	if len(o.materials) == 0 || !o.hasFacesWithoutMaterial() {
+		return ""
+	}
+	name := "default"
+	for i := 1; ; i++ {
+		if _, exists := o.materialMap[name]; !exists {
+			return name
+		}
+		name = fmt.Sprintf("default%d", i)
+	}
+}
+
+// hasFacesWithoutMaterial reports whether any face of the model has no material
+func (o *Model) hasFacesWithoutMaterial() bool {
+	for _, g := range o.groups {
+		for _, r := range g.ranges {
+			if r.material == -1 && r.lastFace > r.firstFace {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// format returns the options and the filename of a map statement, the inverse of parseTextureMap
+func (tm *textureMap) format(dir string) string {
+	var opts []string
+	if tm.scale.X != 1 || tm.scale.Y != 1 || tm.scale.Z != 1 {
+		opts = append(opts, "-s "+formatFloats(tm.scale.X, tm.scale.Y, tm.scale.Z))
+	}
+	if tm.offset.X != 0 || tm.offset.Y != 0 || tm.offset.Z != 0 {
+		opts = append(opts, "-o "+formatFloats(tm.offset.X, tm.offset.Y, tm.offset.Z))
+	}
+	if tm.clamp {
+		opts = append(opts, "-clamp on")
+	}
+	if tm.bumpMultiplier != 1 {
+		opts = append(opts, "-bm "+formatFloats(tm.bumpMultiplier))
+	}
+	filename := tm.tex.Filename
+	// textures which have not been loaded from the file system, like the ones of ParseFS, keep their name
+	if _, err := os.Stat(filename); err == nil && dir != "" {
+		if abs, err := filepath.Abs(filename); err == nil {
+			filename = abs
+		}
+		if absDir, err := filepath.Abs(dir); err == nil {
+			if rel, err := filepath.Rel(absDir, filename); err == nil {
+				filename = rel
+			}
+		}
+	}
+	return strings.Join(append(opts, filepath.ToSlash(filename)), " ")
+}
+
+// formatFloats formats the values with the shortest representation which parses back to the same value
+func formatFloats(values ...float64) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatColor(c m.Vector) string {
+	return formatFloats(c.X, c.Y, c.Z)
+}
@@ -0,0 +1,256 @@
+package obj
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	m "go-3d-rasterizer/math3d"
+)
+
+const roundTripMTL = `
+newmtl red
+Kd 1 0 0
+Ks 0.5 0.5 0.5
+Ns 50
+
+newmtl blue
+Kd 0 0 1
+d 0.5
+illum 4
+`
+
+const roundTripOBJ = `
+mtllib model.mtl
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+v 0 0 1 2
+v 1 0 1
+v 1 1 1
+v 0 1 1
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+vn 0 0 1
+vn 0 1 0
+vn 0.6 0.8 0
+
+g nomat
+f 1 2 3
+o box
+g front
+usemtl red
+s 1
+f 1/1/1 2/2/1 3/3/1 4/4/1
+f 5/1/2 6/2/2 7/3/2
+s off
+f 5/1/3 7/3/3 8/4/3
+g top
+usemtl blue
+f 4//2 3//2 7//2 8//2
+g front
+s 2
+f 1/1 5/2 8/3 4/4
+o other
+g front
+usemtl red
+f 2 6 7 3
+`
+
+// parseRoundTrip writes the obj file and the material library model.mtl into a temporary directory and parses them
+func parseRoundTrip(t *testing.T, obj, mtl string) *Model {
+	t.Helper()
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "model.mtl"), []byte(mtl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(dir, "model.obj")
+	if err := ioutil.WriteFile(filename, []byte(obj), 0644); err != nil {
+		t.Fatal(err)
+	}
+	model, err := ParseFileWithOptions(filename, ParseOptions{Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Warnings()) > 0 {
+		t.Fatalf("unexpected warnings: %v", model.Warnings())
+	}
+	return model
+}
+
+// writeRoundTrip writes the model and parses it again
+func writeRoundTrip(t *testing.T, model *Model) *Model {
+	t.Helper()
+	var obj, mtl bytes.Buffer
+	if err := model.WriteOBJWithOptions(&obj, WriteOptions{MaterialLibrary: "model.mtl"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := model.WriteMTL(&mtl); err != nil {
+		t.Fatal(err)
+	}
+	return parseRoundTrip(t, obj.String(), mtl.String())
+}
+
+// materialName returns the name of the material with the index, faces without material get the default material
+func materialName(o *Model, idx int) string {
+	if idx == -1 {
+		return "default"
+	}
+	return o.materials[idx].name
+}
+
+// withoutAlpha removes the alpha channel of the material colors, mtl colors have none.
+// Colors which are not defined are 0 including the alpha channel, parsed colors are opaque
+func withoutAlpha(mat material) material {
+	mat.idx = 0
+	for _, c := range []*m.Vector{&mat.ambientColor, &mat.diffuseColor, &mat.specularColor, &mat.emissiveColor} {
+		c.W = 0
+	}
+	return mat
+}
+
+// compareModels compares the models field by field, materials are compared by name
+func compareModels(t *testing.T, want, got *Model) {
+	t.Helper()
+	if !reflect.DeepEqual(got.vertices, want.vertices) {
+		t.Errorf("vertices = %v, want %v", got.vertices, want.vertices)
+	}
+	if !reflect.DeepEqual(got.colors, want.colors) {
+		t.Errorf("colors = %v, want %v", got.colors, want.colors)
+	}
+	if !reflect.DeepEqual(got.normals, want.normals) {
+		t.Errorf("normals = %v, want %v", got.normals, want.normals)
+	}
+	if !reflect.DeepEqual(got.texCoords, want.texCoords) {
+		t.Errorf("texture coordinates = %v, want %v", got.texCoords, want.texCoords)
+	}
+	if got.hasSmoothingGroups != want.hasSmoothingGroups {
+		t.Errorf("hasSmoothingGroups = %v, want %v", got.hasSmoothingGroups, want.hasSmoothingGroups)
+	}
+	if !reflect.DeepEqual(got.triangles, want.triangles) {
+		t.Errorf("triangles = %v, want %v", got.triangles, want.triangles)
+	}
+
+	if len(got.faces) != len(want.faces) {
+		t.Fatalf("%d faces, want %d", len(got.faces), len(want.faces))
+	}
+	for i := range want.faces {
+		g, w := got.faces[i], want.faces[i]
+		if gm, wm := materialName(got, g.material), materialName(want, w.material); gm != wm {
+			t.Errorf("face %d: material %q, want %q", i, gm, wm)
+		}
+		g.material, w.material = 0, 0
+		if !reflect.DeepEqual(g, w) {
+			t.Errorf("face %d = %+v, want %+v", i, g, w)
+		}
+	}
+
+	if len(got.groups) != len(want.groups) {
+		t.Fatalf("%d groups, want %d", len(got.groups), len(want.groups))
+	}
+	for i := range want.groups {
+		g, w := got.groups[i], want.groups[i]
+		if g.Name != w.Name || g.Object != w.Object || g.Visible != w.Visible {
+			t.Errorf("group %d = %q/%q visible %v, want %q/%q visible %v", i, g.Object, g.Name, g.Visible, w.Object, w.Name, w.Visible)
+		}
+		if len(g.ranges) != len(w.ranges) {
+			t.Errorf("group %q: %d ranges, want %d", w.Name, len(g.ranges), len(w.ranges))
+			continue
+		}
+		for j := range w.ranges {
+			gr, wr := g.ranges[j], w.ranges[j]
+			if gm, wm := materialName(got, gr.material), materialName(want, wr.material); gm != wm {
+				t.Errorf("group %q, range %d: material %q, want %q", w.Name, j, gm, wm)
+			}
+			gr.material, wr.material = 0, 0
+			if gr != wr {
+				t.Errorf("group %q, range %d = %+v, want %+v", w.Name, j, gr, wr)
+			}
+		}
+	}
+
+	for _, w := range want.materials {
+		idx, ok := got.materialMap[w.name]
+		if !ok {
+			t.Errorf("material %q is missing", w.name)
+			continue
+		}
+		g := withoutAlpha(got.materials[idx])
+		w = withoutAlpha(w)
+		if !reflect.DeepEqual(g, w) {
+			t.Errorf("material %q = %+v, want %+v", w.name, g, w)
+		}
+	}
+}
+
+func TestWriteOBJRoundTrip(t *testing.T) {
+	want := parseRoundTrip(t, roundTripOBJ, roundTripMTL)
+	got := writeRoundTrip(t, want)
+	compareModels(t, want, got)
+
+	// faces without material get the default material, which is added to the material library
+	idx, ok := got.materialMap["default"]
+	if !ok {
+		t.Fatal("the default material is missing")
+	}
+	mat := withoutAlpha(got.materials[idx])
+	if wantMat := withoutAlpha(defaultMaterial("default")); !reflect.DeepEqual(mat, wantMat) {
+		t.Errorf("default material = %+v, want %+v", mat, wantMat)
+	}
+
+	// writing the model again does not change it anymore
+	compareModels(t, got, writeRoundTrip(t, got))
+}
+
+func TestWriteOBJFacesWithoutMaterialAfterMaterial(t *testing.T) {
+	// other formats can have faces without material after faces with one, which can't be expressed in obj files
+	want := parseRoundTrip(t, roundTripOBJ, roundTripMTL)
+	for _, g := range want.groups {
+		if g.Name == "top" {
+			g.ranges[0].material = -1
+			for i := g.ranges[0].firstFace; i < g.ranges[0].lastFace; i++ {
+				want.faces[i].material = -1
+			}
+		}
+	}
+	compareModels(t, want, writeRoundTrip(t, want))
+}
+
+func TestWriteOBJVertexColors(t *testing.T) {
+	const obj = `
+v 0 0 0 1 0 0
+v 1 0 0 0 1 0
+v 1 1 0 0.5 0.25 0.125
+v 0 1 0 0.5 1 0 0
+f 1 2 3 4
+`
+	want := parseRoundTrip(t, obj, "")
+	if len(want.colors) != 4 {
+		t.Fatalf("%d vertex colors, want 4", len(want.colors))
+	}
+	compareModels(t, want, writeRoundTrip(t, want))
+}
+
+func TestWriteOBJMaterialLibrary(t *testing.T) {
+	model := parseRoundTrip(t, roundTripOBJ, roundTripMTL)
+	var buf bytes.Buffer
+	if err := model.WriteOBJWithOptions(&buf, WriteOptions{MaterialLibrary: "model.mtl"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "\nmtllib model.mtl\n") {
+		t.Error("the mtllib statement is missing")
+	}
+	buf.Reset()
+	if err := model.WriteOBJ(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "mtllib") {
+		t.Error("mtllib is written without a material library")
+	}
+}
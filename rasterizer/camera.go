@@ -0,0 +1,92 @@
+package rasterizer
+
+import (
+	m "go-3d-rasterizer/math3d"
+	"math"
+)
+
+// Projection selects how a camera projects the scene onto the screen
+type Projection int
+
+// projections
+const (
+	Perspective Projection = iota
+	Orthographic
+)
+
+// Camera describes the view and the projection of a scene
+type Camera struct {
+	Position   m.Vector
+	Target     m.Vector
+	Up         m.Vector // points upwards on the screen, must not be parallel to the viewing direction
+	Projection Projection
+	Fov        float64 // vertical field of view in degrees
+	// OrthoHeight is the visible height of an orthographic camera in world units, 0 shows the same area
+	// at the target as the perspective projection with the field of view
+	OrthoHeight float64
+	ZNear       float64
+	ZFar        float64
+}
+
+// NewCamera creates a perspective camera at the origin which looks along the negative z-axis
+func NewCamera(fov, zNear, zFar float64) Camera {
+	return Camera{
+		Position: m.Vector{X: 0, Y: 0, Z: 0, W: 1},
+		Target:   m.Vector{X: 0, Y: 0, Z: -1, W: 1},
+		Up:       m.Vector{X: 0, Y: 1, Z: 0, W: 1},
+		Fov:      fov,
+		ZNear:    zNear,
+		ZFar:     zFar,
+	}
+}
+
+// LookAt places the camera at eye and points it at target
+func (c *Camera) LookAt(eye, target, up m.Vector) {
+	c.Position, c.Target, c.Up = eye, target, up
+}
+
+// Orbit places the camera distance units away from the target and points it at the target. The camera is rotated
+// around the target by yaw around the y-axis and by pitch around the x-axis (radians), negative pitches look from above
+func (c *Camera) Orbit(target m.Vector, distance, pitch, yaw float64) {
+	c.OrbitRotation(target, distance, m.QuaternionFromEuler(pitch, yaw, 0, m.XYZ))
+}
+
+// OrbitRotation places the camera distance units away from the target and points it at the target. The camera
+// is rotated around the target by rot, starting in front of the target on the positive z-axis.
+// Interpolating the rotations with m.Slerp moves the camera smoothly between views
+func (c *Camera) OrbitRotation(target m.Vector, distance float64, rot m.Quaternion) {
+	eye := m.Add(target, rot.Rotate(m.Vector{X: 0, Y: 0, Z: distance, W: 0}))
+	up := rot.Rotate(m.Vector{X: 0, Y: 1, Z: 0, W: 0})
+	c.LookAt(eye, target, up)
+}
+
+// ViewMatrix returns the matrix which transforms world space into view space
+func (c *Camera) ViewMatrix() m.Matrix {
+	return m.LookAt(c.Position, c.Target, c.Up)
+}
+
+// ProjectionMatrix returns the projection matrix for a viewport with the given aspect ratio (width / height)
+func (c *Camera) ProjectionMatrix(aspectRatio float64) m.Matrix {
+	if c.Projection == Perspective {
+		return m.ProjectionMatrix(c.Fov, aspectRatio, c.ZNear, c.ZFar)
+	}
+	height := c.OrthoHeight
+	if height == 0 {
+		height = 2 * m.Magnitude(m.Sub(c.Target, c.Position)) * math.Tan(c.Fov*math.Pi/360.)
+	}
+	width := height * aspectRatio
+	return m.OrthoMatrix(-width/2, width/2, -height/2, height/2, c.ZNear, c.ZFar)
+}
+
+// SetCamera replaces the projection matrix and the model view matrix with the ones of the camera.
+// Model transformations have to be applied to the model view matrix afterwards
+func (s *Scene) SetCamera(c Camera) {
+	s.camera = c
+	s.ProjectionMatrix = c.ProjectionMatrix(float64(s.width) / float64(s.height))
+	s.ModelViewMatrix = c.ViewMatrix()
+}
+
+// Camera returns the camera which has been set last
+func (s *Scene) Camera() Camera {
+	return s.camera
+}
@@ -0,0 +1,101 @@
+package rasterizer
+
+import (
+	m "go-3d-rasterizer/math3d"
+	"math"
+)
+
+// clipVertex is a vertex in homogeneous clip space together with the attributes which need to be interpolated
+// when a triangle is split by a clipping plane
+type clipVertex struct {
+	pos      m.Vector
+	varyings []float64
+	invW     float64 // 1/W of the clip space position, set after the perspective divide
+}
+
+// clipPlanes returns the signed distance of a clip space vertex to each of the six frustum planes,
+// the vertex is inside of a plane if the distance is >= 0
+var clipPlanes = [...]func(v m.Vector) float64{
+	func(v m.Vector) float64 { return v.W + v.Z }, // near
+	func(v m.Vector) float64 { return v.W - v.Z }, // far
+	func(v m.Vector) float64 { return v.W + v.X }, // left
+	func(v m.Vector) float64 { return v.W - v.X }, // right
+	func(v m.Vector) float64 { return v.W + v.Y }, // bottom
+	func(v m.Vector) float64 { return v.W - v.Y }, // top
+}
+
+// outcode returns a bitmask with one bit set for each plane the vertex lies outside of
+func outcode(v m.Vector) int {
+	code := 0
+	for i, plane := range clipPlanes {
+		if plane(v) < 0 {
+			code |= 1 << uint(i)
+		}
+	}
+	return code
+}
+
+// lerp4 linearly interpolates all four components, unlike math3d.Lerp which resets W
+func lerp4(v, w m.Vector, t float64) m.Vector {
+	return m.Vector{
+		X: v.X + (w.X-v.X)*t,
+		Y: v.Y + (w.Y-v.Y)*t,
+		Z: v.Z + (w.Z-v.Z)*t,
+		W: v.W + (w.W-v.W)*t,
+	}
+}
+
+func lerpClipVertex(a, b clipVertex, t float64) clipVertex {
+	varyings := make([]float64, len(a.varyings))
+	for i := range varyings {
+		varyings[i] = a.varyings[i] + (b.varyings[i]-a.varyings[i])*t
+	}
+	return clipVertex{pos: lerp4(a.pos, b.pos, t), varyings: varyings}
+}
+
+// clipPolygon clips a convex polygon against the view frustum in homogeneous clip space,
+// using the sutherland-hodgman algorithm found here
+// https://en.wikipedia.org/wiki/Sutherland%E2%80%93Hodgman_algorithm
+func clipPolygon(poly []clipVertex) []clipVertex {
+	for _, plane := range clipPlanes {
+		if len(poly) == 0 {
+			break
+		}
+		out := make([]clipVertex, 0, len(poly)+1)
+		prev := poly[len(poly)-1]
+		prevDist := plane(prev.pos)
+		for _, cur := range poly {
+			curDist := plane(cur.pos)
+			if curDist >= 0 {
+				if prevDist < 0 {
+					out = append(out, lerpClipVertex(prev, cur, prevDist/(prevDist-curDist)))
+				}
+				out = append(out, cur)
+			} else if prevDist >= 0 {
+				out = append(out, lerpClipVertex(prev, cur, prevDist/(prevDist-curDist)))
+			}
+			prev, prevDist = cur, curDist
+		}
+		poly = out
+	}
+	return poly
+}
+
+// clipLine clips the line a-b against the view frustum in homogeneous clip space
+// and returns the parameters t0, t1 of the visible segment, using the liang-barsky algorithm found here
+// https://en.wikipedia.org/wiki/Liang%E2%80%93Barsky_algorithm
+func clipLine(a, b m.Vector) (t0, t1 float64, visible bool) {
+	t0, t1 = 0, 1
+	for _, plane := range clipPlanes {
+		da, db := plane(a), plane(b)
+		if da < 0 && db < 0 {
+			return 0, 0, false
+		}
+		if da < 0 {
+			t0 = math.Max(t0, da/(da-db))
+		} else if db < 0 {
+			t1 = math.Min(t1, da/(da-db))
+		}
+	}
+	return t0, t1, t0 <= t1
+}
@@ -0,0 +1,70 @@
+package rasterizer
+
+import m "go-3d-rasterizer/math3d"
+
+// CullMode selects which triangles are discarded before they get rasterized
+type CullMode int
+
+// Winding is the vertex order of a triangle as seen on the screen
+type Winding int
+
+// cull modes
+const (
+	CullNone CullMode = iota
+	CullBack
+	CullFront
+)
+
+// winding orders
+const (
+	WindingCCW Winding = iota
+	WindingCW
+)
+
+// Stats holds counters about the work done since the buffers have been cleared the last time
+type Stats struct {
+	Triangles        int // triangles passed to DrawTriangle
+	ClippedTriangles int // triangles which were completely outside of the view frustum
+	CulledTriangles  int // triangles discarded by the cull mode
+	CulledObjects    int // models and parts of models skipped by FrustumCull
+}
+
+// signedArea calculates twice the signed area of a polygon in screen coordinates (shoelace formula).
+// Screen coordinates have the y-axis pointing downwards, so counter clockwise polygons have a negative area
+func signedArea(poly []clipVertex) float64 {
+	area := 0.
+	prev := poly[len(poly)-1].pos
+	for _, v := range poly {
+		area += prev.X*v.pos.Y - v.pos.X*prev.Y
+		prev = v.pos
+	}
+	return area
+}
+
+// isCulled determines whether a polygon with the given screen space signed area gets discarded
+func (s *Scene) isCulled(area float64) bool {
+	if s.CullMode == CullNone {
+		return false
+	}
+	ccw := area < 0
+	frontFacing := ccw == (s.FrontFace == WindingCCW)
+	if s.CullMode == CullBack {
+		return !frontFacing
+	}
+	return frontFacing
+}
+
+// Frustum returns the view frustum in model space, which is transformed by the model view matrix
+func (s *Scene) Frustum() m.Frustum {
+	return m.FrustumFromMatrix(s.ModelViewProjectionMatrix())
+}
+
+// FrustumCull reports whether the bounding box in model space is completely outside of the view frustum,
+// so that its triangles don't have to be drawn. Culled boxes are counted in the stats
+func (s *Scene) FrustumCull(b m.AABB) bool {
+	if s.Frustum().ClassifyAABB(b) != m.Outside {
+		return false
+	}
+	s.Stats.CulledObjects++
+	return true
+}
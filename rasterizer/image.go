@@ -0,0 +1,27 @@
+package rasterizer
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Image flushes all pending triangles and converts the frame buffer into an image
+func (s *Scene) Image() *image.NRGBA {
+	s.Flush()
+	img := image.NewNRGBA(image.Rect(0, 0, s.width, s.height))
+	for i, c := range s.Buffers.FrameBuffer {
+		img.SetNRGBA(i%s.width, i/s.width, color.NRGBA{
+			R: colorChannel(c.X),
+			G: colorChannel(c.Y),
+			B: colorChannel(c.Z),
+			A: colorChannel(c.W),
+		})
+	}
+	return img
+}
+
+// colorChannel converts a [0, 1] color channel to a byte
+func colorChannel(v float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255.))
+}
@@ -12,6 +12,19 @@ type Scene struct {
 	ViewportMatrix   m.Matrix
 	Buffers          buffers
 
+	// AffineInterpolation disables perspective correct interpolation of the shader varyings,
+	// this is only useful to compare the results with the old behavior
+	AffineInterpolation bool
+	// CullMode and FrontFace control back-face culling, culling is disabled by default
+	CullMode  CullMode
+	FrontFace Winding
+	// Stats is reset every time the buffers get cleared
+	Stats Stats
+
+	camera   Camera
+	tiles    *tileBins
+	fragment Fragment // scratch space for the serial renderer
+
 	width  int
 	height int
 	wh     int
@@ -22,15 +35,10 @@ type buffers struct {
 	DepthBuffer []float64
 }
 
-// LightingCalcCb is a callback function type for lighting calculation
-type LightingCalcCb func(w, u, t float64, frameBufferIdx int)
-
-// NewScene creates a new scene struct
+// NewScene creates a new scene struct with a perspective camera at the origin, see NewCamera
 func NewScene(winWidth, winHeight, fov, zNear, zFar float64) *Scene {
-	return &Scene{
-		ModelViewMatrix:  m.IdentityMatrix(),
-		ProjectionMatrix: m.ProjectionMatrix(90.0, winWidth/winHeight, 1, 1000),
-		ViewportMatrix:   m.Viewport(0, 0, winWidth, winHeight),
+	s := &Scene{
+		ViewportMatrix: m.Viewport(0, 0, winWidth, winHeight),
 		Buffers: buffers{
 			FrameBuffer: make([]m.Vector, int(winWidth*winHeight)),
 			DepthBuffer: make([]float64, int(winWidth*winHeight)),
@@ -39,10 +47,16 @@ func NewScene(winWidth, winHeight, fov, zNear, zFar float64) *Scene {
 		height: int(winHeight),
 		wh:     int(winWidth * winHeight),
 	}
+	s.SetCamera(NewCamera(fov, zNear, zFar))
+	return s
 }
 
-// ClearBuffers clears the buffers
+// ClearBuffers clears the buffers and resets the stats, triangles which have not been flushed yet are discarded
 func (s *Scene) ClearBuffers(clearColor m.Vector) {
+	s.Stats = Stats{}
+	if s.tiles != nil {
+		s.tiles.reset()
+	}
 	i := 0
 	for y := 0; y < s.height; y++ {
 		for x := 0; x < s.width; x++ {
@@ -55,18 +69,35 @@ func (s *Scene) ClearBuffers(clearColor m.Vector) {
 
 // VectorToScreencoords convertex a vector to screen coordinates
 func (s *Scene) VectorToScreencoords(v m.Vector) m.Vector {
+	return s.clipToScreencoords(s.vectorToClipcoords(v))
+}
+
+// vectorToClipcoords converts a vector to homogeneous clip space coordinates
+func (s *Scene) vectorToClipcoords(v m.Vector) m.Vector {
 	v = m.Transform(s.ModelViewMatrix, v, false)
-	v = m.Transform(s.ProjectionMatrix, v, false)
+	return m.Transform(s.ProjectionMatrix, v, false)
+}
+
+// clipToScreencoords applies the perspective divide and the viewport transformation to a clip space vector
+func (s *Scene) clipToScreencoords(v m.Vector) m.Vector {
 	v = m.Mul(v, 1./v.W)
-	v = m.Transform(s.ViewportMatrix, v, false)
-	return v
+	return m.Transform(s.ViewportMatrix, v, false)
 }
 
 // RasterizeLine draws a line from a to b with the given color, using the bresenham's line algorithm found here
 // https://en.wikipedia.org/wiki/Bresenham%27s_line_algorithm#All_cases
 func (s *Scene) RasterizeLine(a, b, colorA, colorB m.Vector) {
-	a = s.VectorToScreencoords(a)
-	b = s.VectorToScreencoords(b)
+	s.Flush() // lines are not binned, keep the drawing order intact
+	a = s.vectorToClipcoords(a)
+	b = s.vectorToClipcoords(b)
+	t0, t1, visible := clipLine(a, b)
+	if !visible {
+		return
+	}
+	a, b = lerp4(a, b, t0), lerp4(a, b, t1)
+	colorA, colorB = lerp4(colorA, colorB, t0), lerp4(colorA, colorB, t1)
+	a = s.clipToScreencoords(a)
+	b = s.clipToScreencoords(b)
 
 	x0, y0 := int(a.X), int(a.Y)
 	x1, y1 := int(b.X), int(b.Y)
@@ -110,6 +141,149 @@ func (s *Scene) RasterizeLine(a, b, colorA, colorB m.Vector) {
 	}
 }
 
+// RasterizePoint draws a depth tested square of size x size pixels centered at v
+func (s *Scene) RasterizePoint(v, color m.Vector, size int) {
+	s.Flush() // points are not binned, keep the drawing order intact
+	v = s.vectorToClipcoords(v)
+	if outcode(v) != 0 {
+		return
+	}
+	v = s.clipToScreencoords(v)
+	if size < 1 {
+		size = 1
+	}
+	minX, minY := int(v.X)-(size-1)/2, int(v.Y)-(size-1)/2
+	for y := minY; y < minY+size; y++ {
+		for x := minX; x < minX+size; x++ {
+			if y < 0 || y >= s.height || x < 0 || x >= s.width {
+				continue
+			}
+			idx := s.width*y + x
+			if v.Z <= s.Buffers.DepthBuffer[idx] {
+				s.Buffers.FrameBuffer[idx] = color
+				s.Buffers.DepthBuffer[idx] = v.Z
+			}
+		}
+	}
+}
+
+// RasterizeTriangle draws a triangle with the three vectors a, b and c and the given vertex colors
+func (s *Scene) RasterizeTriangle(a, b, c, colorA, colorB, colorC m.Vector) {
+	shader := &colorShader{
+		mvp:      s.ModelViewProjectionMatrix(),
+		vertices: [3]m.Vector{a, b, c},
+		colors:   [3]m.Vector{colorA, colorB, colorC},
+	}
+	s.DrawTriangle(shader, 0, 1, 2)
+}
+
+// DrawTriangle runs the vertex stage of the shader for the vertices a, b and c, clips the triangle against
+// the view frustum and runs the fragment stage for every covered pixel which passes the depth test
+func (s *Scene) DrawTriangle(shader Shader, a, b, c int) {
+	s.Stats.Triangles++
+	poly := make([]clipVertex, 3)
+	poly[0].pos, poly[0].varyings = shader.Vertex(a)
+	poly[1].pos, poly[1].varyings = shader.Vertex(b)
+	poly[2].pos, poly[2].varyings = shader.Vertex(c)
+
+	codeA, codeB, codeC := outcode(poly[0].pos), outcode(poly[1].pos), outcode(poly[2].pos)
+	if codeA&codeB&codeC != 0 {
+		s.Stats.ClippedTriangles++
+		return // all vertices are outside of the same plane
+	}
+	if codeA|codeB|codeC != 0 {
+		poly = clipPolygon(poly)
+		if len(poly) < 3 {
+			s.Stats.ClippedTriangles++
+			return
+		}
+	}
+
+	for i := range poly {
+		poly[i].invW = 1. / poly[i].pos.W
+		poly[i].pos = s.clipToScreencoords(poly[i].pos)
+	}
+
+	area := signedArea(poly)
+	if area == 0 {
+		return // degenerate
+	}
+	if s.isCulled(area) {
+		s.Stats.CulledTriangles++
+		return
+	}
+	for i := 1; i < len(poly)-1; i++ {
+		tri, visible := s.setupTriangle(poly[0], poly[i], poly[i+1], shader)
+		if !visible {
+			continue
+		}
+		if s.tiles != nil {
+			s.tiles.bin(tri)
+		} else {
+			s.rasterizeScreenTriangle(&tri, 0, 0, s.width-1, s.height-1, &s.fragment)
+		}
+	}
+}
+
+// screenTriangle is a clipped triangle in screen coordinates, ready to be rasterized.
+// The barycentric coordinates u and t are linear functions of the pixel position:
+// u(x, y) = u0 + ux*x + uy*y
+type screenTriangle struct {
+	a, b, c clipVertex
+	shader  Shader
+	affine  bool
+	blend   bool
+
+	bbMinX, bbMinY, bbMaxX, bbMaxY int
+
+	u0, ux, uy float64
+	t0, tx, ty float64
+
+	// the rasterizer walks the bounding box incrementally, starting with u and t at bbMinX, bbMinY
+	walkU, walkUX, walkUY float64
+	walkT, walkTX, walkTY float64
+	// tileStarts holds u and t of the walk at the first pixel of every tile of tileSize pixels in every row
+	// of the bounding box, it is nil if the bounding box lies within a single tile
+	tileStarts []float64
+	tileSize   int
+}
+
+// setupTriangle calculates the bounding box and the barycentric coordinate equations of a screen space triangle
+// source: http://gamma.cs.unc.edu/graphicscourse/09_rasterization.pdf (page 32,33)
+func (s *Scene) setupTriangle(va, vb, vc clipVertex, shader Shader) (screenTriangle, bool) {
+	a, b, c := va.pos, vb.pos, vc.pos
+	tri := screenTriangle{a: va, b: vb, c: vc, shader: shader, affine: s.AffineInterpolation}
+	if bs, ok := shader.(BlendShader); ok {
+		tri.blend = bs.Blend()
+	}
+
+	tri.bbMinX = int(math.Max(math.Ceil(math.Min(math.Min(a.X, b.X), c.X)), 0))
+	tri.bbMinY = int(math.Max(math.Ceil(math.Min(math.Min(a.Y, b.Y), c.Y)), 0))
+	tri.bbMaxX = int(math.Min(math.Ceil(math.Max(math.Max(a.X, b.X), c.X)), float64(s.width-1)))
+	tri.bbMaxY = int(math.Min(math.Ceil(math.Max(math.Max(a.Y, b.Y), c.Y)), float64(s.height-1)))
+	if tri.bbMinX > tri.bbMaxX || tri.bbMinY > tri.bbMaxY {
+		return tri, false
+	}
+
+	uDenom := (a.Y-c.Y)*b.X + (c.X-a.X)*b.Y + a.X*c.Y - c.X*a.Y
+	tri.ux = (a.Y - c.Y) / uDenom
+	tri.uy = (c.X - a.X) / uDenom
+	tri.u0 = (a.X*c.Y - c.X*a.Y) / uDenom
+
+	tDenom := (a.Y-b.Y)*c.X + (b.X-a.X)*c.Y + a.X*b.Y - b.X*a.Y
+	tri.tx = (a.Y - b.Y) / tDenom
+	tri.ty = (b.X - a.X) / tDenom
+	tri.t0 = (a.X*b.Y - b.X*a.Y) / tDenom
+
+	tri.walkU = sf(tri.bbMinX, tri.bbMinY, a, b, c)
+	tri.walkUX = sf(tri.bbMinX+1., tri.bbMinY, a, b, c) - tri.walkU
+	tri.walkUY = sf(tri.bbMinX, tri.bbMinY+1., a, b, c) - tri.walkU
+	tri.walkT = tf(tri.bbMinX, tri.bbMinY, a, b, c)
+	tri.walkTX = tf(tri.bbMinX+1., tri.bbMinY, a, b, c) - tri.walkT
+	tri.walkTY = tf(tri.bbMinX, tri.bbMinY+1., a, b, c) - tri.walkT
+	return tri, true
+}
+
 func sf(x, y int, a, b, c m.Vector) float64 {
 	return ((a.Y-c.Y)*float64(x) + (c.X-a.X)*float64(y) + a.X*c.Y - c.X*a.Y) / ((a.Y-c.Y)*b.X + (c.X-a.X)*b.Y + a.X*c.Y - c.X*a.Y)
 }
@@ -118,50 +292,130 @@ func tf(x, y int, a, b, c m.Vector) float64 {
 	return ((a.Y-b.Y)*float64(x) + (b.X-a.X)*float64(y) + a.X*b.Y - b.X*a.Y) / ((a.Y-b.Y)*c.X + (b.X-a.X)*c.Y + a.X*b.Y - b.X*a.Y)
 }
 
-// RasterizeTriangle draws a triangle with the three vectors a, b and c and the given color
-// optimization: incremental barycentric coordinate calculation (u,t)
+// walkTiles walks the bounding box like rasterizeScreenTriangle and records u and t at the first pixel of every tile
+// in every row. Tiles start the walk from there, because the rounding errors of the incremental steps make
+// u and t depend on the path by which a pixel has been reached
+func (tri *screenTriangle) walkTiles(size int) {
+	firstColumn, lastColumn := tri.bbMinX/size, tri.bbMaxX/size
+	if firstColumn == lastColumn && tri.bbMinY/size == tri.bbMaxY/size {
+		return
+	}
+	columns := lastColumn - firstColumn + 1
+	tri.tileSize = size
+	tri.tileStarts = make([]float64, 0, 2*columns*(tri.bbMaxY-tri.bbMinY+1))
+	u, t := tri.walkU, tri.walkT
+	n := float64(tri.bbMaxX - tri.bbMinX + 1)
+	for y := tri.bbMinY; y <= tri.bbMaxY; y++ {
+		next := tri.bbMinX
+		for x := tri.bbMinX; x <= tri.bbMaxX; x++ {
+			if x == next {
+				tri.tileStarts = append(tri.tileStarts, u, t)
+				next = (x/size + 1) * size
+			}
+			u += tri.walkUX
+			t += tri.walkTX
+		}
+		u += tri.walkUY - n*tri.walkUX
+		t += tri.walkTY - n*tri.walkTX
+	}
+}
+
+// varyingWeights converts screen space barycentric coordinates into the weights used to interpolate the varyings
+func (tri *screenTriangle) varyingWeights(w, u, t float64) (float64, float64, float64) {
+	if tri.affine {
+		return w, u, t
+	}
+	return perspectiveCorrect(tri.a.invW, tri.b.invW, tri.c.invW, w, u, t)
+}
+
+// varyingAt interpolates a single varying at the pixel position x, y, which may lie outside of the triangle
+func (tri *screenTriangle) varyingAt(i, x, y int) float64 {
+	u := tri.u0 + tri.uy*float64(y) + tri.ux*float64(x)
+	t := tri.t0 + tri.ty*float64(y) + tri.tx*float64(x)
+	pw, pu, pt := tri.varyingWeights(1.-u-t, u, t)
+	return tri.a.varyings[i]*pw + tri.b.varyings[i]*pu + tri.c.varyings[i]*pt
+}
+
+// rasterizeScreenTriangle draws the part of a triangle which lies inside of the given pixel rectangle.
+// The barycentric coordinates are calculated incrementally, triangles which span several tiles start the walk
+// at the values recorded by walkTiles, so the result does not depend on how the screen is split into tiles
 // source: http://gamma.cs.unc.edu/graphicscourse/09_rasterization.pdf (page 32,33)
-func (s *Scene) RasterizeTriangle(a, b, c, colorA, colorB, colorC m.Vector, lightCalcCb LightingCalcCb) {
-	a = s.VectorToScreencoords(a)
-	b = s.VectorToScreencoords(b)
-	c = s.VectorToScreencoords(c)
-
-	bbMinX := int(math.Ceil(math.Min(math.Min(a.X, b.X), c.X)))
-	bbMinY := int(math.Ceil(math.Min(math.Min(a.Y, b.Y), c.Y)))
-	bbMaxX := int(math.Ceil(math.Max(math.Max(a.X, b.X), c.X)))
-	bbMaxY := int(math.Ceil(math.Max(math.Max(a.Y, b.Y), c.Y)))
-
-	u := sf(bbMinX, bbMinY, a, b, c)
-	ux := sf(bbMinX+1., bbMinY, a, b, c) - u
-	uy := sf(bbMinX, bbMinY+1., a, b, c) - u
-
-	t := tf(bbMinX, bbMinY, a, b, c)
-	tx := tf(bbMinX+1., bbMinY, a, b, c) - t
-	ty := tf(bbMinX, bbMinY+1., a, b, c) - t
-
-	n := float64(bbMaxX - bbMinX + 1)
-
-	for y := bbMinY; y <= bbMaxY; y++ {
-		idxOffset := s.width*y + bbMinX
-		for x := bbMinX; x <= bbMaxX; x++ {
-			insideViewport := x >= 0 && x < s.width && y >= 0 && y < s.height
-			if t >= 0 && u >= 0 && t+u <= 1 && insideViewport {
-				w := 1. - u - t
-				depth := a.Z*w + b.Z*u + c.Z*t
-				if depth >= 0. && depth <= s.Buffers.DepthBuffer[idxOffset] {
-					s.Buffers.FrameBuffer[idxOffset] = lerpTriColor(colorA, colorB, colorC, w, u, t)
-					s.Buffers.DepthBuffer[idxOffset] = depth
-					if lightCalcCb != nil {
-						lightCalcCb(w, u, t, idxOffset)
-					}
+func (s *Scene) rasterizeScreenTriangle(tri *screenTriangle, minX, minY, maxX, maxY int, frag *Fragment) {
+	if cap(frag.Varyings) < len(tri.a.varyings) {
+		frag.Varyings = make([]float64, len(tri.a.varyings))
+	}
+	frag.Varyings = frag.Varyings[:len(tri.a.varyings)]
+
+	if tri.tileStarts == nil {
+		// the rectangle contains the whole bounding box
+		u, t := tri.walkU, tri.walkT
+		n := float64(tri.bbMaxX - tri.bbMinX + 1)
+		for y := tri.bbMinY; y <= tri.bbMaxY; y++ {
+			idxOffset := s.width*y + tri.bbMinX
+			for x := tri.bbMinX; x <= tri.bbMaxX; x++ {
+				if t >= 0 && u >= 0 && t+u <= 1 {
+					s.shadePixel(tri, x, y, idxOffset, u, t, frag)
 				}
+				idxOffset++
+				u += tri.walkUX
+				t += tri.walkTX
+			}
+			u += tri.walkUY - n*tri.walkUX
+			t += tri.walkTY - n*tri.walkTX
+		}
+		return
+	}
+
+	if tri.bbMinX > minX {
+		minX = tri.bbMinX
+	}
+	if tri.bbMinY > minY {
+		minY = tri.bbMinY
+	}
+	if tri.bbMaxX < maxX {
+		maxX = tri.bbMaxX
+	}
+	if tri.bbMaxY < maxY {
+		maxY = tri.bbMaxY
+	}
+	firstColumn := tri.bbMinX / tri.tileSize
+	columns := tri.bbMaxX/tri.tileSize - firstColumn + 1
+	column := minX/tri.tileSize - firstColumn
+	for y := minY; y <= maxY; y++ {
+		idxOffset := s.width*y + minX
+		start := 2 * ((y-tri.bbMinY)*columns + column)
+		u, t := tri.tileStarts[start], tri.tileStarts[start+1]
+		for x := minX; x <= maxX; x++ {
+			if t >= 0 && u >= 0 && t+u <= 1 {
+				s.shadePixel(tri, x, y, idxOffset, u, t, frag)
 			}
 			idxOffset++
-			u += ux
-			t += tx
+			u += tri.walkUX
+			t += tri.walkTX
+		}
+	}
+}
+
+// shadePixel depth tests the pixel x, y with the barycentric coordinates u, t and runs the fragment shader
+func (s *Scene) shadePixel(tri *screenTriangle, x, y, idxOffset int, u, t float64, frag *Fragment) {
+	va, vb, vc := tri.a, tri.b, tri.c
+	w := 1. - u - t
+	depth := va.pos.Z*w + vb.pos.Z*u + vc.pos.Z*t
+	if depth < 0. || depth > s.Buffers.DepthBuffer[idxOffset] {
+		return
+	}
+	pw, pu, pt := tri.varyingWeights(w, u, t)
+	for i := range frag.Varyings {
+		frag.Varyings[i] = va.varyings[i]*pw + vb.varyings[i]*pu + vc.varyings[i]*pt
+	}
+	frag.X, frag.Y, frag.Depth, frag.tri = x, y, depth, tri
+	if color, keep := tri.shader.Fragment(frag); keep {
+		if tri.blend {
+			s.Buffers.FrameBuffer[idxOffset] = blendColor(color, s.Buffers.FrameBuffer[idxOffset])
+		} else {
+			s.Buffers.FrameBuffer[idxOffset] = color
+			s.Buffers.DepthBuffer[idxOffset] = depth
 		}
-		u += uy - n*ux
-		t += ty - n*tx
 	}
 }
 
@@ -192,8 +446,8 @@ func (s *Scene) DrawAxisLines(size float64) {
 
 // DrawQuad renders 2 triangles
 func (s *Scene) DrawQuad(a, b, c, d m.Vector, ca, cb, cc, cd m.Vector) {
-	s.RasterizeTriangle(a, b, c, ca, cb, cc, nil)
-	s.RasterizeTriangle(a, c, d, ca, cc, cd, nil)
+	s.RasterizeTriangle(a, b, c, ca, cb, cc)
+	s.RasterizeTriangle(a, c, d, ca, cc, cd)
 }
 
 // DrawCube renders a cube
@@ -222,6 +476,17 @@ func (s *Scene) DrawCube(center, color m.Vector, size float64) {
 	s.DrawQuad(v[2], v[3], v[7], v[6], color, color, color, color)
 }
 
+// perspectiveCorrect converts screen space barycentric coordinates into perspective correct ones,
+// by interpolating the varyings divided by W and dividing by the interpolated 1/W afterwards
+// source: https://www.comp.nus.edu.sg/~lowkl/publications/lowk_persp_interp_techrep.pdf
+func perspectiveCorrect(invWA, invWB, invWC, w, u, t float64) (float64, float64, float64) {
+	w *= invWA
+	u *= invWB
+	t *= invWC
+	sum := w + u + t
+	return w / sum, u / sum, t / sum
+}
+
 func lerpTriColor(c1, c2, c3 m.Vector, s, t, u float64) m.Vector {
 	return m.Add(m.Add(m.Mul(c1, s), m.Mul(c2, t)), m.Mul(c3, u))
 }
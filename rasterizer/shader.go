@@ -0,0 +1,73 @@
+package rasterizer
+
+import (
+	m "go-3d-rasterizer/math3d"
+)
+
+// Shader is a programmable pipeline, consisting of a vertex and a fragment stage.
+// When tiled rendering is enabled, Fragment gets called from multiple goroutines at once
+// and possibly after DrawTriangle has returned, so a shader must not be modified while it is in use
+type Shader interface {
+	// Vertex transforms the vertex with the given index into homogeneous clip space
+	// and returns the varyings, which get interpolated across the triangle.
+	// The meaning of the index is up to the shader, all vertices of a triangle need to return the same amount of varyings
+	Vertex(idx int) (m.Vector, []float64)
+	// Fragment calculates the color of a pixel, returning false discards the fragment
+	Fragment(f *Fragment) (m.Vector, bool)
+}
+
+// BlendShader is a shader whose fragments get blended with the frame buffer, using the alpha channel of the fragment color.
+// Blended fragments do not update the depth buffer, so they need to be drawn after the opaque geometry
+type BlendShader interface {
+	Shader
+	// Blend reports whether the fragments of the shader get blended
+	Blend() bool
+}
+
+// blendColor blends src over dst
+func blendColor(src, dst m.Vector) m.Vector {
+	a := src.W
+	return m.Vector{
+		X: src.X*a + dst.X*(1-a),
+		Y: src.Y*a + dst.Y*(1-a),
+		Z: src.Z*a + dst.Z*(1-a),
+		W: a + dst.W*(1-a),
+	}
+}
+
+// Fragment holds the input of the fragment stage
+type Fragment struct {
+	X, Y     int
+	Depth    float64
+	Varyings []float64 // perspective correct interpolated varyings, only valid during the Fragment call
+
+	tri *screenTriangle
+}
+
+// Derivatives returns the screen space derivatives of the varying with index i,
+// which is the change of the varying towards the next pixel on the x- and y-axis
+func (f *Fragment) Derivatives(i int) (float64, float64) {
+	v := f.tri.varyingAt(i, f.X, f.Y)
+	return f.tri.varyingAt(i, f.X+1, f.Y) - v, f.tri.varyingAt(i, f.X, f.Y+1) - v
+}
+
+// ModelViewProjectionMatrix returns the matrix which transforms a vector into clip space
+func (s *Scene) ModelViewProjectionMatrix() m.Matrix {
+	return m.Multiply(s.ProjectionMatrix, s.ModelViewMatrix)
+}
+
+// colorShader interpolates the vertex colors of a single triangle, it is used by RasterizeTriangle
+type colorShader struct {
+	mvp      m.Matrix
+	vertices [3]m.Vector
+	colors   [3]m.Vector
+}
+
+func (cs *colorShader) Vertex(idx int) (m.Vector, []float64) {
+	c := cs.colors[idx]
+	return m.Transform(cs.mvp, cs.vertices[idx], false), []float64{c.X, c.Y, c.Z, c.W}
+}
+
+func (cs *colorShader) Fragment(f *Fragment) (m.Vector, bool) {
+	return m.Vector{X: f.Varyings[0], Y: f.Varyings[1], Z: f.Varyings[2], W: f.Varyings[3]}, true
+}
@@ -0,0 +1,102 @@
+package rasterizer
+
+import (
+	"runtime"
+	"sync"
+)
+
+// tileBins holds the triangles which have been submitted since the last flush,
+// sorted into the screen tiles their bounding box overlaps
+type tileBins struct {
+	size      int
+	columns   int
+	rows      int
+	triangles []screenTriangle
+	bins      [][]int
+}
+
+func newTileBins(width, height, size int) *tileBins {
+	columns := (width + size - 1) / size
+	rows := (height + size - 1) / size
+	return &tileBins{
+		size:    size,
+		columns: columns,
+		rows:    rows,
+		bins:    make([][]int, columns*rows),
+	}
+}
+
+// bin queues a triangle in every tile its bounding box overlaps
+func (t *tileBins) bin(tri screenTriangle) {
+	tri.walkTiles(t.size)
+	idx := len(t.triangles)
+	t.triangles = append(t.triangles, tri)
+	for row := tri.bbMinY / t.size; row <= tri.bbMaxY/t.size; row++ {
+		for col := tri.bbMinX / t.size; col <= tri.bbMaxX/t.size; col++ {
+			bin := &t.bins[row*t.columns+col]
+			*bin = append(*bin, idx)
+		}
+	}
+}
+
+// reset empties all bins, the allocated memory is kept for the next frame
+func (t *tileBins) reset() {
+	t.triangles = t.triangles[:0]
+	for i := range t.bins {
+		t.bins[i] = t.bins[i][:0]
+	}
+}
+
+// EnableTiledRendering switches the scene to a binned renderer: triangles are only transformed and sorted into
+// screen tiles of tileSize*tileSize pixels by DrawTriangle, the tiles get rasterized in parallel by Flush.
+// The output is pixel-identical to the serial renderer
+func (s *Scene) EnableTiledRendering(tileSize int) {
+	s.Flush()
+	if tileSize <= 0 {
+		s.tiles = nil
+		return
+	}
+	s.tiles = newTileBins(s.width, s.height, tileSize)
+}
+
+// DisableTiledRendering flushes all pending triangles and switches back to the serial renderer
+func (s *Scene) DisableTiledRendering() {
+	s.EnableTiledRendering(0)
+}
+
+// Flush rasterizes all binned triangles, using a worker pool sized to GOMAXPROCS.
+// Each tile is processed by a single worker in submission order, so the depth test behaves just like in the serial renderer.
+// Flush must be called before the buffers are read, it does nothing if tiled rendering is disabled
+func (s *Scene) Flush() {
+	t := s.tiles
+	if t == nil || len(t.triangles) == 0 {
+		return
+	}
+
+	tileCh := make(chan int, len(t.bins))
+	for i, bin := range t.bins {
+		if len(bin) > 0 {
+			tileCh <- i
+		}
+	}
+	close(tileCh)
+
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var frag Fragment
+			for tile := range tileCh {
+				minX := (tile % t.columns) * t.size
+				minY := (tile / t.columns) * t.size
+				maxX, maxY := minX+t.size-1, minY+t.size-1
+				for _, idx := range t.bins[tile] {
+					s.rasterizeScreenTriangle(&t.triangles[idx], minX, minY, maxX, maxY, &frag)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	t.reset()
+}
@@ -0,0 +1,97 @@
+package rasterizer
+
+import (
+	"image/color"
+	"image/png"
+	"math"
+	"math/rand"
+	"os"
+	"runtime"
+	"testing"
+
+	m "go-3d-rasterizer/math3d"
+)
+
+// newTestScene creates a scene with the projection of the serial renderer before tiled rendering was added
+func newTestScene() *Scene {
+	s := NewScene(160, 120, 90, 1, 1000)
+	s.ProjectionMatrix = m.ProjectionMatrix(90, 160./120., 1, 1000)
+	return s
+}
+
+// renderTriangles draws overlapping triangles at different depths, some of them cross the screen borders
+// and the near plane or are behind the camera
+func renderTriangles(s *Scene) {
+	rnd := rand.New(rand.NewSource(1))
+	s.ClearBuffers(m.Vector{X: 0, Y: 0, Z: 0, W: 1})
+	s.CullMode = CullBack
+	for i := 0; i < 300; i++ {
+		var v, c [3]m.Vector
+		for j := range v {
+			z := 1 - rnd.Float64()*9
+			v[j] = m.Vector{X: (rnd.Float64()*2.4 - 1.2) * math.Abs(z), Y: (rnd.Float64()*2 - 1) * math.Abs(z), Z: z, W: 1}
+			c[j] = m.Vector{X: rnd.Float64(), Y: rnd.Float64(), Z: rnd.Float64(), W: 1}
+		}
+		s.RasterizeTriangle(v[0], v[1], v[2], c[0], c[1], c[2])
+	}
+	s.Flush()
+}
+
+func TestTiledRenderingMatchesSerial(t *testing.T) {
+	serial := newTestScene()
+	renderTriangles(serial)
+
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+	for _, procs := range []int{1, 2, 4, 8} {
+		runtime.GOMAXPROCS(procs)
+		for _, tileSize := range []int{7, 16, 64} {
+			tiled := newTestScene()
+			tiled.EnableTiledRendering(tileSize)
+			renderTriangles(tiled)
+			if tiled.Stats != serial.Stats {
+				t.Errorf("GOMAXPROCS %d, tile size %d: stats %+v, want %+v", procs, tileSize, tiled.Stats, serial.Stats)
+			}
+			for i := range serial.Buffers.FrameBuffer {
+				if tiled.Buffers.FrameBuffer[i] != serial.Buffers.FrameBuffer[i] {
+					t.Fatalf("GOMAXPROCS %d, tile size %d: color of pixel %d is %v, want %v", procs, tileSize, i,
+						tiled.Buffers.FrameBuffer[i], serial.Buffers.FrameBuffer[i])
+				}
+				if math.Float64bits(tiled.Buffers.DepthBuffer[i]) != math.Float64bits(serial.Buffers.DepthBuffer[i]) {
+					t.Fatalf("GOMAXPROCS %d, tile size %d: depth of pixel %d is %v, want %v", procs, tileSize, i,
+						tiled.Buffers.DepthBuffer[i], serial.Buffers.DepthBuffer[i])
+				}
+			}
+		}
+	}
+}
+
+// TestRenderingMatchesGolden compares the serial and the tiled renderer with testdata/triangles.png,
+// which has been rendered by the serial renderer before tiled rendering was added
+func TestRenderingMatchesGolden(t *testing.T) {
+	file, err := os.Open("testdata/triangles.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	golden, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tileSize := range []int{0, 7, 16, 64} {
+		s := newTestScene()
+		s.EnableTiledRendering(tileSize)
+		renderTriangles(s)
+		img := s.Image()
+		if img.Bounds() != golden.Bounds() {
+			t.Fatalf("tile size %d: image bounds %v, want %v", tileSize, img.Bounds(), golden.Bounds())
+		}
+		for y := 0; y < img.Rect.Dy(); y++ {
+			for x := 0; x < img.Rect.Dx(); x++ {
+				if got, want := img.NRGBAAt(x, y), color.NRGBAModel.Convert(golden.At(x, y)); got != want {
+					t.Fatalf("tile size %d: pixel %d, %d is %v, want %v", tileSize, x, y, got, want)
+				}
+			}
+		}
+	}
+}